@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// adminSnapshotHandler flushes the current dataset to durable storage.
+func adminSnapshotHandler(c *gin.Context) {
+	if err := db.Snapshot(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+}
+
+// adminCompactHandler rewrites the durable log to drop obsolete history,
+// for backends that support it.
+func adminCompactHandler(c *gin.Context) {
+	if err := db.Compact(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/Kazooki123/lunardb_api/internal/auth/keys"
+	"github.com/gin-gonic/gin"
+)
+
+// authRotateHandler generates a new signing key, retiring the current one
+// to verify-only so tokens signed before the rotation keep validating.
+func authRotateHandler(c *gin.Context) {
+	var request struct {
+		Algorithm string `json:"algorithm"`
+	}
+	// Body is optional; default to HS256 when omitted or unparsable.
+	_ = c.ShouldBindJSON(&request)
+
+	alg := keys.HS256
+	if request.Algorithm != "" {
+		alg = keys.Algorithm(request.Algorithm)
+	}
+
+	key, err := authKeys.Rotate(alg)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"kid":        key.ID,
+		"algorithm":  key.Algorithm,
+		"created_at": key.CreatedAt,
+	})
+}
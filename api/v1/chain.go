@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/Kazooki123/lunardb_api/internal/chain"
+	"github.com/Kazooki123/lunardb_api/internal/middleware"
+	"github.com/gin-gonic/gin"
+)
+
+// setRequestBody is the {"key":...,"value":...} shape POST /api/v1/set
+// expects; the chain's terminal handler and its schema-validate
+// interceptor both need to read it.
+type setRequestBody struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// buildSetChain wires /api/v1/set through the interceptor chain: auth ->
+// rate-limit -> quota -> audit-log -> schema-validate -> storage. Other KV
+// routes still go through APIKeyMiddleware/RateLimitGinMiddleware directly;
+// they can move onto the same chain the same way as this one did.
+func buildSetChain(keyManager *APIKeyManager, limiter *middleware.IPRateLimiter, quota *chain.QuotaManager) *chain.Chain {
+	terminal := chain.HandlerFunc(func(req *chain.Request) (*chain.Response, error) {
+		var body setRequestBody
+		if err := json.Unmarshal(req.Body, &body); err != nil || body.Key == "" || body.Value == "" {
+			return &chain.Response{Status: http.StatusBadRequest, Body: []byte(`{"error":"key and value are required"}`)}, nil
+		}
+
+		db.Set(body.Key, body.Value)
+		if namespace, id, namespaced := splitNamespacedKey(body.Key); namespaced {
+			indexNamespacedWrite(namespace, id, body.Value)
+		}
+
+		return &chain.Response{Status: http.StatusOK, Body: []byte(`{"result":"OK"}`)}, nil
+	})
+
+	return chain.New(terminal,
+		chain.NamedInterceptor{Name: "auth", Interceptor: chain.NewAuthInterceptor(keyManager)},
+		chain.NamedInterceptor{Name: "ratelimit", Interceptor: chain.NewRateLimitInterceptor(limiter)},
+		chain.NamedInterceptor{Name: "quota", Interceptor: chain.NewQuotaInterceptor(quota)},
+		chain.NamedInterceptor{Name: "audit-log", Interceptor: chain.NewAuditLogInterceptor()},
+		chain.NamedInterceptor{Name: "schema-validate", Interceptor: chain.NewSchemaValidateInterceptor(schemaRegistry, extractSetRecord)},
+	)
+}
+
+// extractSetRecord pulls the namespace/id/value a schema-validate
+// interceptor needs out of a setRequestBody-shaped request body.
+func extractSetRecord(req *chain.Request) (namespace, id string, value []byte, ok bool) {
+	var body setRequestBody
+	if err := json.Unmarshal(req.Body, &body); err != nil {
+		return "", "", nil, false
+	}
+	namespace, id, namespaced := splitNamespacedKey(body.Key)
+	if !namespaced {
+		return "", "", nil, false
+	}
+	return namespace, id, []byte(body.Value), true
+}
+
+// ginChainHandler adapts a *chain.Chain into a gin.HandlerFunc.
+func ginChainHandler(c *chain.Chain) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		body, err := io.ReadAll(ctx.Request.Body)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		req := &chain.Request{
+			Method:     ctx.Request.Method,
+			Path:       ctx.Request.URL.Path,
+			RemoteAddr: ctx.Request.RemoteAddr,
+			Header:     ctx.Request.Header,
+			Body:       body,
+			Context:    &chain.MiddlewareContext{Route: ctx.FullPath(), RequestID: ctx.GetHeader("X-Request-ID")},
+		}
+
+		resp, err := c.ServeRequest(req)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		ctx.Data(resp.Status, "application/json; charset=utf-8", resp.Body)
+	}
+}
@@ -0,0 +1,41 @@
+//go:build !enterprise
+
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// featureGate decides whether a premium feature is enabled for the running
+// server. The AGPL build and the enterprise build each provide their own.
+type featureGate interface {
+	Allows(feature string) bool
+}
+
+// closedGate is the AGPL build's featureGate: no license subsystem is
+// compiled in, so every premium feature stays disabled.
+type closedGate struct{}
+
+func (closedGate) Allows(feature string) bool { return false }
+
+// AGPL is the open-source build's edition constructor. It never links the
+// enterprise license-checking code path.
+func AGPL() (string, featureGate) {
+	return "agpl", closedGate{}
+}
+
+// newLicenseManager is a no-op in the AGPL build: there's no license
+// subsystem compiled in, so there's nothing to construct.
+func newLicenseManager(store licenseStore) (interface{}, error) {
+	return nil, nil
+}
+
+// newEdition resolves to AGPL() or Enterprise() depending on the build tag
+// this binary was compiled with; main.go calls it unconditionally.
+func newEdition(mgr interface{}) (string, featureGate) {
+	return AGPL()
+}
+
+// registerEnterpriseRoutes is a no-op in the AGPL build: the routes that
+// manage enterprise-only behavior simply don't exist.
+func registerEnterpriseRoutes(v1 *gin.RouterGroup, mgr interface{}, admin gin.HandlerFunc) {}
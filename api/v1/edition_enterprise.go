@@ -0,0 +1,55 @@
+//go:build enterprise
+
+package main
+
+import (
+	"github.com/Kazooki123/lunardb_api/internal/licensing"
+	"github.com/gin-gonic/gin"
+)
+
+// featureGate decides whether a premium feature is enabled for the running
+// server. The AGPL build and the enterprise build each provide their own.
+type featureGate interface {
+	Allows(feature string) bool
+}
+
+// licensedGate is the enterprise build's featureGate: a feature is enabled
+// when some currently installed, non-expired license grants it.
+type licensedGate struct {
+	manager *licensing.Manager
+}
+
+func (g licensedGate) Allows(feature string) bool {
+	return g.manager.HasFeature(feature)
+}
+
+// Enterprise is the enterprise build's edition constructor, wiring the
+// license manager into route gating.
+func Enterprise(mgr *licensing.Manager) (string, featureGate) {
+	return "enterprise", licensedGate{manager: mgr}
+}
+
+// newLicenseManager builds the real license manager. This is the only
+// build-tagged function that constructs a *licensing.Manager; callers in
+// main.go hold it as an opaque interface{} so the AGPL build never needs to
+// know its concrete type.
+func newLicenseManager(store licenseStore) (interface{}, error) {
+	return licensing.NewManager(store)
+}
+
+// newEdition resolves to AGPL() or Enterprise() depending on the build tag
+// this binary was compiled with; main.go calls it unconditionally.
+func newEdition(mgr interface{}) (string, featureGate) {
+	return Enterprise(mgr.(*licensing.Manager))
+}
+
+// registerEnterpriseRoutes wires the admin license endpoints onto v1, gated
+// behind admin like /auth/rotate and /admin/snapshot|compact: a license
+// enumerates every customer's account_id/feature_set/jti and can revoke any
+// customer's license, so a regular API key must not reach it.
+func registerEnterpriseRoutes(v1 *gin.RouterGroup, mgr interface{}, admin gin.HandlerFunc) {
+	m := mgr.(*licensing.Manager)
+	v1.POST("/licenses", admin, licensesCreateHandler(m))
+	v1.GET("/licenses", admin, licensesListHandler(m))
+	v1.DELETE("/licenses/:jti", admin, licensesDeleteHandler(m))
+}
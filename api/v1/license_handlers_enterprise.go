@@ -0,0 +1,71 @@
+//go:build enterprise
+
+package main
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Kazooki123/lunardb_api/internal/licensing"
+	"github.com/gin-gonic/gin"
+)
+
+// licensesCreateHandler ingests a signed license token and installs it.
+func licensesCreateHandler(mgr *licensing.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var request struct {
+			Token string `json:"token" binding:"required"`
+		}
+		if err := c.ShouldBindJSON(&request); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		license, err := mgr.Install(request.Token)
+		if err != nil {
+			c.JSON(licenseErrorStatus(err), gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusCreated, gin.H{"license": license})
+	}
+}
+
+// licensesListHandler returns every currently installed license.
+func licensesListHandler(mgr *licensing.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		licenses, err := mgr.List()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"licenses": licenses})
+	}
+}
+
+// licensesDeleteHandler removes an installed license by jti.
+func licensesDeleteHandler(mgr *licensing.Manager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		jti := c.Param("jti")
+		if !mgr.Delete(jti) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "License not found"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"result": "OK"})
+	}
+}
+
+// licenseErrorStatus maps a licensing error to the HTTP status code that
+// best reflects why the license was rejected.
+func licenseErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, licensing.ErrInvalidSignature):
+		return http.StatusUnauthorized
+	case errors.Is(err, licensing.ErrExpired), errors.Is(err, licensing.ErrNotYetValid):
+		return http.StatusUnprocessableEntity
+	case errors.Is(err, licensing.ErrDuplicateJTI):
+		return http.StatusConflict
+	default:
+		return http.StatusBadRequest
+	}
+}
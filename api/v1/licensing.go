@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requireFeature rejects requests to premium endpoints when the running
+// edition's featureGate doesn't grant the named feature.
+func requireFeature(gate featureGate, feature string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !gate.Allows(feature) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "feature not licensed: " + feature})
+			return
+		}
+		c.Next()
+	}
+}
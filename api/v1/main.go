@@ -36,25 +36,35 @@ import (
 	"log"
 	"crypto/rand"
     "encoding/base64"
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
 	"sync"
+	"time"
 
+	"github.com/Kazooki123/lunardb_api/internal/auth/keys"
+	"github.com/Kazooki123/lunardb_api/internal/chain"
+	"github.com/Kazooki123/lunardb_api/internal/middleware"
+	"github.com/Kazooki123/lunardb_api/internal/schema"
+	"github.com/Kazooki123/lunardb_api/internal/storage"
 	"github.com/gin-gonic/gin"
 )
 
 type APIKeyManager struct {
-    keys map[string]bool
-    mu   sync.RWMutex
+    keys      map[string]bool
+    adminKeys map[string]bool
+    mu        sync.RWMutex
 }
 
 type LunarDB struct {
-	data map[string]string
-	mu   sync.RWMutex
+	engine storage.Engine
 }
 
 func NewAPIKeyManager() *APIKeyManager {
     return &APIKeyManager{
-        keys: make(map[string]bool),
+        keys:      make(map[string]bool),
+        adminKeys: make(map[string]bool),
     }
 }
 
@@ -76,6 +86,21 @@ func (m *APIKeyManager) ValidateKey(key string) bool {
     return m.keys[key]
 }
 
+// AddAdminKey registers key as both a regular API key and an admin key,
+// granting it access to admin-only routes like /api/v1/auth/rotate.
+func (m *APIKeyManager) AddAdminKey(key string) {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.keys[key] = true
+    m.adminKeys[key] = true
+}
+
+func (m *APIKeyManager) IsAdminKey(key string) bool {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+    return m.adminKeys[key]
+}
+
 func APIKeyMiddleware(keyManager *APIKeyManager) gin.HandlerFunc {
     return func(c *gin.Context) {
         key := c.GetHeader("X-API-Key")
@@ -87,53 +112,187 @@ func APIKeyMiddleware(keyManager *APIKeyManager) gin.HandlerFunc {
     }
 }
 
-func NewLunarDB() *LunarDB {
-	return &LunarDB{
-		data: make(map[string]string),
+// AdminKeyMiddleware additionally requires the validated API key to be
+// registered as an admin key.
+func AdminKeyMiddleware(keyManager *APIKeyManager) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        key := c.GetHeader("X-API-Key")
+        if key == "" || !keyManager.IsAdminKey(key) {
+            c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Admin API key required"})
+            return
+        }
+        c.Next()
+    }
+}
+
+// RateLimitGinMiddleware enforces limiter's rulesets for the matched gin
+// route, keyed by whatever extractor that route's ruleset declares. It backs
+// every v1 route except /set, which already enforces rate limiting via
+// chain.NewRateLimitInterceptor as part of buildSetChain.
+func RateLimitGinMiddleware(limiter *middleware.IPRateLimiter) gin.HandlerFunc {
+    return func(c *gin.Context) {
+        decision, err := limiter.Allow(c.Request, c.FullPath())
+        if err != nil {
+            c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+            return
+        }
+        if !decision.Allowed {
+            c.Header("Retry-After", strconv.Itoa(int(decision.RetryAfter.Seconds())))
+            c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+            c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+            c.Header("X-RateLimit-Reset", strconv.Itoa(int(decision.Reset.Seconds())))
+            c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": fmt.Sprintf("rate limit exceeded (%s)", decision.RejectedBy)})
+            return
+        }
+        c.Next()
+    }
+}
+
+// NewLunarDB builds the storage engine selected by cfg and replays any
+// existing durable log before serving requests.
+func NewLunarDB(cfg storage.Config) (*LunarDB, error) {
+	engine, err := storage.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if err := engine.Replay(); err != nil {
+		return nil, fmt.Errorf("replay storage engine: %w", err)
 	}
+	return &LunarDB{engine: engine}, nil
 }
 
 func (db *LunarDB) Set(key, value string) {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-	db.data[key] = value
+	if err := db.engine.Set(key, value); err != nil {
+		log.Printf("storage: set %q: %v", key, err)
+	}
 }
 
 func (db *LunarDB) Get(key string) (string, bool) {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-	value, exists := db.data[key]
+	value, exists, err := db.engine.Get(key)
+	if err != nil {
+		log.Printf("storage: get %q: %v", key, err)
+		return "", false
+	}
 	return value, exists
 }
 
 func (db *LunarDB) Del(key string) bool {
-	db.mu.Lock()
-	defer db.mu.Unlock()
-	_, exists := db.data[key]
-	if exists {
-		delete(db.data, key)
+	existed, err := db.engine.Del(key)
+	if err != nil {
+		log.Printf("storage: del %q: %v", key, err)
 	}
-	return exists
+	return existed
 }
 
 func (db *LunarDB) Keys() []string {
-	db.mu.RLock()
-	defer db.mu.RUnlock()
-	keys := make([]string, 0, len(db.data))
-	for k := range db.data {
-		keys = append(keys, k)
+	keys, err := db.engine.Keys()
+	if err != nil {
+		log.Printf("storage: keys: %v", err)
+		return nil
 	}
 	return keys
 }
 
+// Snapshot flushes the current dataset to durable storage.
+func (db *LunarDB) Snapshot() error {
+	return db.engine.Snapshot()
+}
+
+// compactor is implemented by engines (currently AOFEngine) that support
+// rewriting their durable log to drop obsolete history.
+type compactor interface {
+	Compact() error
+}
+
+// Compact rewrites the durable log to only the current dataset, if the
+// active engine supports it.
+func (db *LunarDB) Compact() error {
+	c, ok := db.engine.(compactor)
+	if !ok {
+		return fmt.Errorf("storage: backend does not support compaction")
+	}
+	return c.Compact()
+}
+
+// storageConfigFromEnv builds a storage.Config from the LUNARDB_STORAGE_*
+// environment variables, defaulting to the in-memory backend (data lost on
+// restart) when LUNARDB_STORAGE_BACKEND is unset.
+func storageConfigFromEnv() storage.Config {
+	cfg := storage.Config{
+		Backend:      os.Getenv("LUNARDB_STORAGE_BACKEND"),
+		AOFPath:      os.Getenv("LUNARDB_AOF_PATH"),
+		Fsync:        storage.FsyncPolicy(os.Getenv("LUNARDB_AOF_FSYNC")),
+		EmbeddedPath: os.Getenv("LUNARDB_EMBEDDED_PATH"),
+	}
+	if maxBytes := os.Getenv("LUNARDB_AOF_MAX_BYTES"); maxBytes != "" {
+		if n, err := strconv.ParseInt(maxBytes, 10, 64); err == nil {
+			cfg.AOFMaxBytes = n
+		} else {
+			log.Printf("storage: ignoring invalid LUNARDB_AOF_MAX_BYTES %q: %v", maxBytes, err)
+		}
+	}
+	return cfg
+}
+
+// storageBackendName reports the effective backend name for logging, since
+// an empty Config.Backend defaults to "memory" inside storage.New.
+func storageBackendName(cfg storage.Config) string {
+	if cfg.Backend == "" {
+		return "memory"
+	}
+	return cfg.Backend
+}
+
+// newRateLimiterFromEnv builds the IPRateLimiter from the route rulesets in
+// LUNARDB_RATE_LIMIT_CONFIG (see middleware.LoadRateLimitConfig for the YAML
+// shape), falling back to a single default ruleset (5 req/s, burst 10)
+// applied to every route when the env var isn't set.
+func newRateLimiterFromEnv() *middleware.IPRateLimiter {
+	path := os.Getenv("LUNARDB_RATE_LIMIT_CONFIG")
+	if path == "" {
+		return middleware.NewIPRateLimiter(5, 10)
+	}
+
+	routes, err := middleware.LoadRateLimitConfig(path)
+	if err != nil {
+		log.Fatalf("Failed to load rate limit config: %v", err)
+	}
+	return middleware.NewIPRateLimiterFromRoutes(routes)
+}
+
+// licenseStore is the storage interface a license manager needs; *LunarDB
+// already satisfies it. Declared here instead of importing
+// internal/licensing so that newLicenseManager's AGPL-build stub doesn't
+// pull the licensing package (RSA/JWT parsing, embedded key) into the
+// open-source binary at all.
+type licenseStore interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+	Del(key string) bool
+	Keys() []string
+}
+
 var (
-	db *LunarDB
-	keyManager *APIKeyManager
+	db             *LunarDB
+	keyManager     *APIKeyManager
+	licenseManager interface{} // concrete type supplied by newLicenseManager; see edition_agpl.go/edition_enterprise.go
+	authKeys       *keys.Manager
+	schemaRegistry *schema.Registry
+	edition        string
+	gate           featureGate
+	setChain       *chain.Chain
+	rateLimiter    *middleware.IPRateLimiter
 )
 
 // Main
 func main() {
-	db = NewLunarDB()
+	var err error
+	storageCfg := storageConfigFromEnv()
+	db, err = NewLunarDB(storageCfg)
+	if err != nil {
+		log.Fatalf("Failed to start storage engine: %v", err)
+	}
+	log.Printf("Storage backend: %s", storageBackendName(storageCfg))
 	keyManager = NewAPIKeyManager()
 
 	// Generate and add an initial API key
@@ -141,6 +300,36 @@ func main() {
     keyManager.AddKey(initialKey)
     log.Printf("Initial API Key: %s", initialKey)
 
+	// Generate and add an initial admin API key, used for admin-only
+	// routes like POST /api/v1/auth/rotate
+	initialAdminKey := keyManager.GenerateKey()
+	keyManager.AddAdminKey(initialAdminKey)
+	log.Printf("Initial Admin API Key: %s", initialAdminKey)
+
+	licenseManager, err = newLicenseManager(db)
+	if err != nil {
+		log.Fatalf("Failed to start license manager: %v", err)
+	}
+	edition, gate = newEdition(licenseManager)
+	log.Printf("Edition: %s", edition)
+
+	// authKeys starts with no knowledge of any previous process's keys (see
+	// keys.NewManager): every restart generates a brand-new HS256 key here,
+	// which permanently invalidates tokens signed before the restart.
+	// Deployments that need rotation to survive a restart should seed this
+	// from keys.LoadFromEnv/LoadFromFile instead of GenerateKey.
+	authKeys = keys.NewManager(db)
+	if _, err := authKeys.GenerateKey(keys.HS256); err != nil {
+		log.Fatalf("Failed to generate initial signing key: %v", err)
+	}
+
+	schemaRegistry = schema.NewRegistry(db)
+
+	rateLimiter = newRateLimiterFromEnv()
+	rateLimiter.StartCleanup(time.Hour)
+	quotaManager := chain.NewQuotaManager(0) // 0 = unlimited until an operator configures one
+	setChain = buildSetChain(keyManager, rateLimiter, quotaManager)
+
 	r := gin.Default()
 
 	// Setup routes
@@ -162,32 +351,26 @@ func setupRoutes(r *gin.Engine) {
 
 	r.GET("/health", healthHandler)
 
+	// /set runs through the auth -> ratelimit -> quota -> audit-log ->
+	// schema-validate -> storage interceptor chain instead of
+	// APIKeyMiddleware; see buildSetChain.
+	r.Group("/api/v1").POST("/set", ginChainHandler(setChain))
+
 	// Protected routes (w/ api):
 	v1 := r.Group("/api/v1")
-	v1.Use(APIKeyMiddleware(keyManager))
+	v1.Use(APIKeyMiddleware(keyManager), RateLimitGinMiddleware(rateLimiter))
 	{
-		v1.POST("/set", setHandler)
 		v1.GET("/get/:key", getHandler)
 		v1.DELETE("/del/:key", delHandler)
 		v1.GET("/keys", keysHandler)
-		v1.POST("/query", queryHandler)
-		v1.POST("/schema", schemaHandler)
-	}
-}
-
-func setHandler(c *gin.Context) {
-	var request struct {
-		Key   string `json:"key" binding:"required"`
-		Value string `json:"value" binding:"required"`
-	}
+		v1.POST("/query", requireFeature(gate, "query"), queryHandler)
+		v1.POST("/schema", requireFeature(gate, "schema"), schemaHandler)
+		v1.POST("/auth/rotate", AdminKeyMiddleware(keyManager), authRotateHandler)
+		v1.POST("/admin/snapshot", AdminKeyMiddleware(keyManager), adminSnapshotHandler)
+		v1.POST("/admin/compact", AdminKeyMiddleware(keyManager), adminCompactHandler)
 
-	if err := c.ShouldBindJSON(&request); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
-		return
+		registerEnterpriseRoutes(v1, licenseManager, AdminKeyMiddleware(keyManager))
 	}
-
-	db.Set(request.Key, request.Value)
-	c.JSON(http.StatusOK, gin.H{"result": "OK"})
 }
 
 func getHandler(c *gin.Context) {
@@ -207,6 +390,11 @@ func delHandler(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Key not found"})
 		return
 	}
+
+	if namespace, id, namespaced := splitNamespacedKey(key); namespaced {
+		schemaRegistry.IndexDelete(namespace, id)
+	}
+
 	c.JSON(http.StatusOK, gin.H{"result": "OK"})
 }
 
@@ -219,10 +407,3 @@ func healthHandler(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"status": "healthy"})
 }
 
-func queryHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Query endpoint not implemented yet"})
-}
-
-func schemaHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{"message": "Schema endpoint not implemented yet"})
-}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/Kazooki123/lunardb_api/internal/query"
+	"github.com/Kazooki123/lunardb_api/internal/schema"
+	"github.com/gin-gonic/gin"
+)
+
+// splitNamespacedKey splits a "namespace/id" key into its two parts. Keys
+// without a "/" are not namespaced and have no schema applied.
+func splitNamespacedKey(key string) (namespace, id string, ok bool) {
+	i := strings.IndexByte(key, '/')
+	if i < 0 {
+		return "", "", false
+	}
+	return key[:i], key[i+1:], true
+}
+
+// indexNamespacedWrite updates the registry's secondary indexes after a
+// namespaced write has been accepted.
+func indexNamespacedWrite(namespace, id, value string) {
+	var record map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &record); err != nil {
+		return
+	}
+	for _, field := range schemaRegistry.IndexedFields(namespace) {
+		if v, ok := record[field]; ok {
+			schemaRegistry.IndexUpdate(namespace, field, fmt.Sprintf("%v", v), id)
+		}
+	}
+}
+
+func schemaHandler(c *gin.Context) {
+	var def schema.Definition
+	if err := c.ShouldBindJSON(&def); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := schemaRegistry.Register(def); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"result": "OK"})
+}
+
+func queryHandler(c *gin.Context) {
+	var q query.Query
+	if err := c.ShouldBindJSON(&q); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if q.From == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from is required"})
+		return
+	}
+
+	result, err := query.Execute(schemaRegistry, namespaceSource{namespace: q.From}, q)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// namespaceSource implements query.Source over db, LunarDB's KV store,
+// treating every "namespace/id" key as one record.
+type namespaceSource struct {
+	namespace string
+}
+
+func (s namespaceSource) ScanAll() ([]query.Record, error) {
+	var records []query.Record
+	prefix := s.namespace + "/"
+	for _, key := range db.Keys() {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if record, ok := s.decode(key); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s namespaceSource) FetchByIDs(ids []string) ([]query.Record, error) {
+	records := make([]query.Record, 0, len(ids))
+	for _, id := range ids {
+		if record, ok := s.decode(s.namespace + "/" + id); ok {
+			records = append(records, record)
+		}
+	}
+	return records, nil
+}
+
+func (s namespaceSource) decode(key string) (query.Record, bool) {
+	value, exists := db.Get(key)
+	if !exists {
+		return query.Record{}, false
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(value), &fields); err != nil {
+		return query.Record{}, false
+	}
+	_, id, _ := splitNamespacedKey(key)
+	return query.Record{ID: id, Fields: fields}, true
+}
@@ -0,0 +1,300 @@
+package keys
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Algorithm is a signing algorithm supported by the key-management package.
+type Algorithm string
+
+const (
+	HS256 Algorithm = "HS256"
+	RS256 Algorithm = "RS256"
+	ES256 Algorithm = "ES256"
+)
+
+// Key is one signing key in the rotation. Active keys sign new tokens;
+// retired keys are kept around verify-only so tokens signed before a
+// rotation keep validating until they expire.
+type Key struct {
+	ID        string // the "kid" header value
+	Algorithm Algorithm
+	CreatedAt time.Time
+	RetiredAt *time.Time
+
+	secret     []byte      // HS256
+	privateKey interface{} // *rsa.PrivateKey or *ecdsa.PrivateKey; nil once retired
+	verifyKey  interface{} // []byte, *rsa.PublicKey or *ecdsa.PublicKey
+}
+
+// VerifyOnly reports whether this key has been retired and can no longer be
+// used to sign new tokens.
+func (k *Key) VerifyOnly() bool {
+	return k.RetiredAt != nil
+}
+
+// SigningMethod returns the jwt-go signing method matching k.Algorithm.
+func (k *Key) SigningMethod() jwt.SigningMethod {
+	switch k.Algorithm {
+	case RS256:
+		return jwt.SigningMethodRS256
+	case ES256:
+		return jwt.SigningMethodES256
+	default:
+		return jwt.SigningMethodHS256
+	}
+}
+
+// SigningKey returns the material used to sign new tokens with this key.
+func (k *Key) SigningKey() (interface{}, error) {
+	if k.VerifyOnly() {
+		return nil, fmt.Errorf("auth/keys: key %q is retired, verify-only", k.ID)
+	}
+	if k.Algorithm == HS256 {
+		return k.secret, nil
+	}
+	return k.privateKey, nil
+}
+
+// metadata is the subset of a Key persisted to the metadata store; key
+// material (secrets/private keys) never leaves the process. This is an
+// audit trail of rotation history only — there is no corresponding load
+// path, and there deliberately can't be one for HS256 without a KMS behind
+// Store, since the secret itself was never written down. A new Manager
+// always starts empty; see the NewManager doc comment.
+type metadata struct {
+	ID        string     `json:"id"`
+	Algorithm Algorithm  `json:"algorithm"`
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"`
+}
+
+const storeKeyPrefix = "__authkey:"
+
+// Store is the subset of LunarDB's storage API used to persist key
+// metadata across restarts.
+type Store interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+	Keys() []string
+}
+
+// Manager maintains a rotating set of signing keys and resolves the right
+// verification key for an incoming token via its "kid" header.
+type Manager struct {
+	mu        sync.RWMutex
+	keys      map[string]*Key
+	activeKID string
+	store     Store
+}
+
+// NewManager creates an empty Manager. Callers seed it with an initial key
+// via GenerateKey, LoadFromEnv, or LoadFromFile before issuing tokens.
+//
+// A Manager never reloads keys from store on startup: store only ever holds
+// non-secret rotation metadata (see persistMetadata), not key material, so
+// there is nothing to reconstruct a working key from. Every process restart
+// therefore starts with zero keys, and whatever GenerateKey/LoadFromEnv/
+// LoadFromFile call seeds it with a brand-new kid — tokens signed by a
+// previous process become permanently unverifiable (Keyfunc returns "unknown
+// kid"). Production deployments that need rotation to survive a restart
+// must use LoadFromEnv/LoadFromFile against a secret that itself survives
+// the restart (env var, mounted file, KMS), not store.
+func NewManager(store Store) *Manager {
+	return &Manager{keys: make(map[string]*Key), store: store}
+}
+
+// LoadFromEnv seeds the Manager with a single active HS256 key whose secret
+// comes from the given environment variable. Intended for simple
+// deployments; production setups should prefer LoadFromFile. Store is never
+// a source of key material (see NewManager).
+func LoadFromEnv(store Store, envVar string) (*Manager, error) {
+	secret := os.Getenv(envVar)
+	if secret == "" {
+		return nil, fmt.Errorf("auth/keys: environment variable %q is not set", envVar)
+	}
+	m := NewManager(store)
+	if _, err := m.addHS256(secret); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// LoadFromFile seeds the Manager from a JSON file of the form
+// {"algorithm":"HS256","secret":"..."} — a stand-in for a KMS-backed
+// secrets file.
+func LoadFromFile(store Store, path string) (*Manager, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("auth/keys: read key file: %w", err)
+	}
+	var file struct {
+		Algorithm Algorithm `json:"algorithm"`
+		Secret    string    `json:"secret"`
+	}
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("auth/keys: parse key file: %w", err)
+	}
+	m := NewManager(store)
+	switch file.Algorithm {
+	case "", HS256:
+		if _, err := m.addHS256(file.Secret); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("auth/keys: key file algorithm %q requires GenerateKey", file.Algorithm)
+	}
+	return m, nil
+}
+
+// GenerateKey creates a new key for alg, retires the current active key
+// (if any) so it becomes verify-only, and makes the new key active.
+func (m *Manager) GenerateKey(alg Algorithm) (*Key, error) {
+	switch alg {
+	case HS256:
+		secret := make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("auth/keys: generate HS256 secret: %w", err)
+		}
+		return m.addHS256(string(secret))
+	case RS256:
+		priv, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("auth/keys: generate RS256 key: %w", err)
+		}
+		return m.addAsymmetric(RS256, priv, &priv.PublicKey)
+	case ES256:
+		priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("auth/keys: generate ES256 key: %w", err)
+		}
+		return m.addAsymmetric(ES256, priv, &priv.PublicKey)
+	default:
+		return nil, fmt.Errorf("auth/keys: unsupported algorithm %q", alg)
+	}
+}
+
+// Rotate is GenerateKey with a name that matches how callers (e.g. the
+// POST /api/v1/auth/rotate handler) think about the operation.
+func (m *Manager) Rotate(alg Algorithm) (*Key, error) {
+	return m.GenerateKey(alg)
+}
+
+func (m *Manager) addHS256(secret string) (*Key, error) {
+	key := &Key{
+		ID:        newKID(),
+		Algorithm: HS256,
+		CreatedAt: time.Now(),
+		secret:    []byte(secret),
+		verifyKey: []byte(secret),
+	}
+	return m.activate(key)
+}
+
+func (m *Manager) addAsymmetric(alg Algorithm, priv, pub interface{}) (*Key, error) {
+	key := &Key{
+		ID:         newKID(),
+		Algorithm:  alg,
+		CreatedAt:  time.Now(),
+		privateKey: priv,
+		verifyKey:  pub,
+	}
+	return m.activate(key)
+}
+
+func (m *Manager) activate(key *Key) (*Key, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if current, ok := m.keys[m.activeKID]; ok {
+		retiredAt := time.Now()
+		current.RetiredAt = &retiredAt
+		current.privateKey = nil
+		m.persistMetadata(current)
+	}
+
+	m.keys[key.ID] = key
+	m.activeKID = key.ID
+	m.persistMetadata(key)
+
+	return key, nil
+}
+
+// persistMetadata writes a key's non-secret metadata to the store as a
+// record of rotation history (e.g. for an operator auditing when a key was
+// created/retired). It is write-only: nothing reads this back to restore a
+// Manager's state, since the key material it would need is never written
+// here. Must be called with m.mu held.
+func (m *Manager) persistMetadata(key *Key) {
+	if m.store == nil {
+		return
+	}
+	encoded, err := json.Marshal(metadata{
+		ID:        key.ID,
+		Algorithm: key.Algorithm,
+		CreatedAt: key.CreatedAt,
+		RetiredAt: key.RetiredAt,
+	})
+	if err != nil {
+		return
+	}
+	m.store.Set(storeKeyPrefix+key.ID, string(encoded))
+}
+
+// ActiveKey returns the key currently used to sign new tokens.
+func (m *Manager) ActiveKey() (*Key, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[m.activeKID]
+	if !ok {
+		return nil, fmt.Errorf("auth/keys: no active signing key")
+	}
+	return key, nil
+}
+
+// Lookup returns the key with the given kid, including retired ones, so
+// tokens signed before a rotation keep validating.
+func (m *Manager) Lookup(kid string) (*Key, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	key, ok := m.keys[kid]
+	return key, ok
+}
+
+// Keyfunc resolves the verification material for a token via its "kid"
+// header, for use with jwt.ParseWithClaims.
+func (m *Manager) Keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok || kid == "" {
+		return nil, fmt.Errorf("auth/keys: token missing kid header")
+	}
+
+	key, ok := m.Lookup(kid)
+	if !ok {
+		return nil, fmt.Errorf("auth/keys: unknown kid %q", kid)
+	}
+
+	if key.SigningMethod().Alg() != token.Method.Alg() {
+		return nil, fmt.Errorf("auth/keys: token alg %q does not match key %q alg %q", token.Method.Alg(), kid, key.SigningMethod().Alg())
+	}
+
+	return key.verifyKey, nil
+}
+
+func newKID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return fmt.Sprintf("%x", b)
+}
@@ -0,0 +1,94 @@
+package keys
+
+import (
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// fakeStore is a minimal in-memory Store for tests that don't need a real
+// LunarDB instance.
+type fakeStore struct {
+	data map[string]string
+}
+
+func newFakeStore() *fakeStore { return &fakeStore{data: make(map[string]string)} }
+
+func (s *fakeStore) Get(key string) (string, bool) { v, ok := s.data[key]; return v, ok }
+func (s *fakeStore) Set(key, value string)         { s.data[key] = value }
+func (s *fakeStore) Keys() []string {
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestManagerRotateKeepsOldKeyVerifyOnly(t *testing.T) {
+	m := NewManager(newFakeStore())
+
+	first, err := m.GenerateKey(HS256)
+	if err != nil {
+		t.Fatalf("GenerateKey (first): %v", err)
+	}
+
+	token := jwt.NewWithClaims(first.SigningMethod(), jwt.MapClaims{"user_id": "u1"})
+	token.Header["kid"] = first.ID
+	signingKey, err := first.SigningKey()
+	if err != nil {
+		t.Fatalf("SigningKey: %v", err)
+	}
+	signed, err := token.SignedString(signingKey)
+	if err != nil {
+		t.Fatalf("SignedString: %v", err)
+	}
+
+	if _, err := m.Rotate(HS256); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	// A token signed with the now-retired key must still validate via
+	// Keyfunc's kid lookup.
+	parsed, err := jwt.Parse(signed, m.Keyfunc)
+	if err != nil || !parsed.Valid {
+		t.Fatalf("Parse token signed by retired key: valid=%v err=%v, want valid", parsed != nil && parsed.Valid, err)
+	}
+
+	retired, ok := m.Lookup(first.ID)
+	if !ok {
+		t.Fatalf("Lookup(%q) after rotation: not found", first.ID)
+	}
+	if !retired.VerifyOnly() {
+		t.Errorf("retired key VerifyOnly() = false, want true after rotation")
+	}
+	if _, err := retired.SigningKey(); err == nil {
+		t.Errorf("SigningKey() on retired key = nil error, want error (retired keys must not sign new tokens)")
+	}
+}
+
+func TestKeyfuncRejectsUnknownKID(t *testing.T) {
+	m := NewManager(newFakeStore())
+	if _, err := m.GenerateKey(HS256); err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	token := &jwt.Token{Header: map[string]interface{}{"kid": "does-not-exist"}, Method: jwt.SigningMethodHS256}
+	if _, err := m.Keyfunc(token); err == nil {
+		t.Errorf("Keyfunc with unknown kid = nil error, want error")
+	}
+}
+
+func TestKeyfuncRejectsAlgMismatch(t *testing.T) {
+	m := NewManager(newFakeStore())
+	key, err := m.GenerateKey(HS256)
+	if err != nil {
+		t.Fatalf("GenerateKey: %v", err)
+	}
+
+	// Same kid, but the token claims an algorithm the key wasn't issued
+	// under — must be rejected rather than trusting the token's own alg.
+	token := &jwt.Token{Header: map[string]interface{}{"kid": key.ID}, Method: jwt.SigningMethodRS256}
+	if _, err := m.Keyfunc(token); err == nil {
+		t.Errorf("Keyfunc with mismatched alg = nil error, want error")
+	}
+}
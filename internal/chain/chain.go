@@ -0,0 +1,115 @@
+// Package chain models request handling as a reverse-proxy-style pipeline:
+// an ordered list of interceptors (auth, rate-limit, quota, audit-log,
+// schema-validate, ...) wrapping a terminal Handler (typically storage).
+// Each interceptor is independently constructable and testable, and the
+// whole pipeline is agnostic to the HTTP router in front of it, so the
+// current gin routes can move over one at a time, or be swapped for a
+// chi/mux-based router, without rewriting the auth/rate-limit logic itself.
+//
+// Only POST /api/v1/set has actually moved onto a Chain so far (see
+// api/v1/chain.go's buildSetChain), wired together directly in Go. There is
+// no per-route YAML config or interceptor registry yet: build one (a
+// factory registry plus a config loader mapping route patterns to named
+// interceptor lists) if/when a second route needs its own distinct
+// interceptor ordering, rather than growing buildSetChain-style functions
+// per route.
+package chain
+
+import "net/http"
+
+// MiddlewareContext carries the state interceptors resolve about a request
+// down the chain, so later interceptors (and the terminal Handler) don't
+// need to re-derive it.
+type MiddlewareContext struct {
+	APIKey    string
+	License   interface{} // *licensing.License once an auth/license interceptor resolves one
+	Route     string
+	RequestID string
+}
+
+// Request is the router-agnostic representation of an inbound request a
+// Chain processes.
+type Request struct {
+	Method     string
+	Path       string
+	RemoteAddr string
+	Header     http.Header
+	Body       []byte
+
+	// Context carries state resolved by earlier interceptors. It is never
+	// nil: the Chain seeds an empty one before running the first interceptor.
+	Context *MiddlewareContext
+}
+
+// Response is the router-agnostic result of processing a Request.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// Handler processes a Request into a Response. Both the terminal handler
+// and every interceptor-wrapped stage implement this.
+type Handler interface {
+	ServeRequest(req *Request) (*Response, error)
+}
+
+// HandlerFunc adapts a plain function to a Handler.
+type HandlerFunc func(req *Request) (*Response, error)
+
+func (f HandlerFunc) ServeRequest(req *Request) (*Response, error) {
+	return f(req)
+}
+
+// Interceptor wraps a Handler with logic that runs before and/or after
+// delegating to it, e.g. authentication, rate limiting, or audit logging.
+type Interceptor interface {
+	Intercept(next Handler) Handler
+}
+
+// InterceptorFunc adapts a plain function to an Interceptor.
+type InterceptorFunc func(next Handler) Handler
+
+func (f InterceptorFunc) Intercept(next Handler) Handler {
+	return f(next)
+}
+
+// Chain threads a Request through an ordered list of interceptors before it
+// reaches the terminal Handler.
+type Chain struct {
+	names   []string
+	handler Handler
+}
+
+// New builds a Chain that runs interceptors in order, outermost call being
+// the first interceptor given, then hands off to terminal.
+func New(terminal Handler, interceptors ...NamedInterceptor) *Chain {
+	handler := terminal
+	names := make([]string, len(interceptors))
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		handler = interceptors[i].Interceptor.Intercept(handler)
+		names[i] = interceptors[i].Name
+	}
+	return &Chain{handler: handler, names: names}
+}
+
+// NamedInterceptor pairs an Interceptor with the name it was given in New,
+// kept around for diagnostics (e.g. audit logs, error messages).
+type NamedInterceptor struct {
+	Name        string
+	Interceptor Interceptor
+}
+
+// Names returns the interceptor names in execution order.
+func (c *Chain) Names() []string {
+	return c.names
+}
+
+// ServeRequest runs req through every interceptor and the terminal handler.
+// If req.Context is nil, an empty one is seeded first.
+func (c *Chain) ServeRequest(req *Request) (*Response, error) {
+	if req.Context == nil {
+		req.Context = &MiddlewareContext{}
+	}
+	return c.handler.ServeRequest(req)
+}
@@ -0,0 +1,135 @@
+package chain
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/Kazooki123/lunardb_api/internal/middleware"
+	"github.com/Kazooki123/lunardb_api/internal/schema"
+)
+
+// APIKeyValidator is the subset of api/v1's APIKeyManager the auth
+// interceptor needs. Defined here so chain doesn't depend on package main.
+type APIKeyValidator interface {
+	ValidateKey(key string) bool
+}
+
+// NewAuthInterceptor rejects requests whose X-API-Key header doesn't
+// validate against validator, and records the key on req.Context.
+func NewAuthInterceptor(validator APIKeyValidator) Interceptor {
+	return InterceptorFunc(func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*Response, error) {
+			key := req.Header.Get("X-API-Key")
+			if key == "" || !validator.ValidateKey(key) {
+				return &Response{Status: http.StatusUnauthorized, Body: []byte(`{"error":"Invalid API key"}`)}, nil
+			}
+			req.Context.APIKey = key
+			return next.ServeRequest(req)
+		})
+	})
+}
+
+// NewRateLimitInterceptor enforces limiter's rulesets for req.Context.Route,
+// translating a Request into the *http.Request limiter.Allow expects.
+func NewRateLimitInterceptor(limiter *middleware.IPRateLimiter) Interceptor {
+	return InterceptorFunc(func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*Response, error) {
+			httpReq := &http.Request{
+				RemoteAddr: req.RemoteAddr,
+				Header:     req.Header,
+			}
+
+			decision, err := limiter.Allow(httpReq, req.Context.Route)
+			if err != nil {
+				return nil, err
+			}
+			if !decision.Allowed {
+				return &Response{Status: http.StatusTooManyRequests, Body: []byte(`{"error":"rate limit exceeded"}`)}, nil
+			}
+			return next.ServeRequest(req)
+		})
+	})
+}
+
+// QuotaManager tracks how many requests each API key has made against a
+// fixed ceiling, independent of (and typically looser than) rate limiting:
+// rate limiting smooths burstiness, quotas cap total usage.
+type QuotaManager struct {
+	limit  int
+	counts map[string]int
+}
+
+// NewQuotaManager creates a QuotaManager allowing up to limit requests per
+// API key for the manager's lifetime.
+func NewQuotaManager(limit int) *QuotaManager {
+	return &QuotaManager{limit: limit, counts: make(map[string]int)}
+}
+
+// Allow increments apiKey's usage count and reports whether it's still
+// within the quota.
+func (q *QuotaManager) Allow(apiKey string) bool {
+	q.counts[apiKey]++
+	return q.limit <= 0 || q.counts[apiKey] <= q.limit
+}
+
+// NewQuotaInterceptor rejects requests once req.Context.APIKey has exceeded
+// manager's quota. Must run after an interceptor that sets APIKey (e.g. the
+// auth interceptor).
+func NewQuotaInterceptor(manager *QuotaManager) Interceptor {
+	return InterceptorFunc(func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*Response, error) {
+			if !manager.Allow(req.Context.APIKey) {
+				return &Response{Status: http.StatusTooManyRequests, Body: []byte(`{"error":"quota exceeded"}`)}, nil
+			}
+			return next.ServeRequest(req)
+		})
+	})
+}
+
+// NewSchemaValidateInterceptor validates a write's value against the
+// schema registered for its namespace. extract pulls the namespace, record
+// ID, and raw JSON value out of req (the request body's shape is up to the
+// caller, e.g. a {"key":...,"value":...} envelope); ok is false for writes
+// that aren't namespaced.
+func NewSchemaValidateInterceptor(reg *schema.Registry, extract func(req *Request) (namespace, id string, value []byte, ok bool)) Interceptor {
+	return InterceptorFunc(func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*Response, error) {
+			namespace, id, value, ok := extract(req)
+			if ok {
+				if _, registered := reg.Get(namespace); registered {
+					var record map[string]interface{}
+					if err := json.Unmarshal(value, &record); err != nil {
+						return &Response{Status: http.StatusBadRequest, Body: []byte(`{"error":"value must be a JSON object"}`)}, nil
+					}
+					if errs := reg.ValidateRecord(namespace, id, record, func(field, fieldValue string) bool {
+						return reg.HasUnique(namespace, field, fieldValue, id)
+					}); len(errs) > 0 {
+						body, _ := json.Marshal(map[string]interface{}{"errors": errs})
+						return &Response{Status: http.StatusUnprocessableEntity, Body: body}, nil
+					}
+				}
+			}
+			return next.ServeRequest(req)
+		})
+	})
+}
+
+// NewAuditLogInterceptor logs every request's method, path, resolved route,
+// API key, and duration once the rest of the chain has run.
+func NewAuditLogInterceptor() Interceptor {
+	return InterceptorFunc(func(next Handler) Handler {
+		return HandlerFunc(func(req *Request) (*Response, error) {
+			start := time.Now()
+			resp, err := next.ServeRequest(req)
+			status := 0
+			if resp != nil {
+				status = resp.Status
+			}
+			log.Printf("audit: method=%s path=%s route=%s api_key=%s request_id=%s status=%d duration=%s",
+				req.Method, req.Path, req.Context.Route, req.Context.APIKey, req.Context.RequestID, status, time.Since(start))
+			return resp, err
+		})
+	})
+}
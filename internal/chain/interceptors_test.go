@@ -0,0 +1,89 @@
+package chain
+
+import (
+	"net/http"
+	"testing"
+)
+
+// fakeValidator is a minimal APIKeyValidator for tests.
+type fakeValidator map[string]bool
+
+func (v fakeValidator) ValidateKey(key string) bool { return v[key] }
+
+func okTerminal() Handler {
+	return HandlerFunc(func(req *Request) (*Response, error) {
+		return &Response{Status: http.StatusOK, Body: []byte("ok")}, nil
+	})
+}
+
+func TestAuthInterceptorRejectsMissingOrInvalidKey(t *testing.T) {
+	validator := fakeValidator{"good-key": true}
+	handler := NewAuthInterceptor(validator).Intercept(okTerminal())
+
+	for _, key := range []string{"", "bad-key"} {
+		req := &Request{Header: http.Header{}, Context: &MiddlewareContext{}}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		resp, err := handler.ServeRequest(req)
+		if err != nil {
+			t.Fatalf("ServeRequest(key=%q): %v", key, err)
+		}
+		if resp.Status != http.StatusUnauthorized {
+			t.Errorf("ServeRequest(key=%q) status = %d, want %d", key, resp.Status, http.StatusUnauthorized)
+		}
+	}
+}
+
+func TestAuthInterceptorAllowsValidKeyAndRecordsIt(t *testing.T) {
+	validator := fakeValidator{"good-key": true}
+	handler := NewAuthInterceptor(validator).Intercept(okTerminal())
+
+	req := &Request{Header: http.Header{}, Context: &MiddlewareContext{}}
+	req.Header.Set("X-API-Key", "good-key")
+
+	resp, err := handler.ServeRequest(req)
+	if err != nil {
+		t.Fatalf("ServeRequest: %v", err)
+	}
+	if resp.Status != http.StatusOK {
+		t.Errorf("status = %d, want %d", resp.Status, http.StatusOK)
+	}
+	if req.Context.APIKey != "good-key" {
+		t.Errorf("Context.APIKey = %q, want %q", req.Context.APIKey, "good-key")
+	}
+}
+
+func TestQuotaInterceptorRejectsOnceLimitExceeded(t *testing.T) {
+	manager := NewQuotaManager(2)
+	handler := NewQuotaInterceptor(manager).Intercept(okTerminal())
+
+	req := &Request{Context: &MiddlewareContext{APIKey: "user-1"}}
+
+	for i := 0; i < 2; i++ {
+		resp, err := handler.ServeRequest(req)
+		if err != nil {
+			t.Fatalf("ServeRequest (request %d): %v", i+1, err)
+		}
+		if resp.Status != http.StatusOK {
+			t.Fatalf("ServeRequest (request %d) status = %d, want %d (within quota)", i+1, resp.Status, http.StatusOK)
+		}
+	}
+
+	resp, err := handler.ServeRequest(req)
+	if err != nil {
+		t.Fatalf("ServeRequest (3rd request): %v", err)
+	}
+	if resp.Status != http.StatusTooManyRequests {
+		t.Errorf("ServeRequest (3rd request) status = %d, want %d (quota exceeded)", resp.Status, http.StatusTooManyRequests)
+	}
+}
+
+func TestQuotaManagerZeroLimitIsUnlimited(t *testing.T) {
+	manager := NewQuotaManager(0)
+	for i := 0; i < 100; i++ {
+		if !manager.Allow("user-1") {
+			t.Fatalf("Allow (request %d) = false, want true for an unlimited (0) quota", i+1)
+		}
+	}
+}
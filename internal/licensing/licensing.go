@@ -0,0 +1,223 @@
+package licensing
+
+import (
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// embeddedPublicKeyPEM verifies license tokens signed by the LunarDB license
+// server. Rotate by updating this constant; it intentionally has no private
+// counterpart checked into the repo.
+const embeddedPublicKeyPEM = `-----BEGIN PUBLIC KEY-----
+MIIBIjANBgkqhkiG9w0BAQEFAAOCAQ8AMIIBCgKCAQEAleGTHogxQc+1n7dj3EtZ
+jwPuYPPb6/sQibgABUhWLSXVlIlxfyiicoW+5XwH+o2ye/eDwwrlzLGa0n+2+3IV
+fzXmmbAZUjoGT18rzwSp9J3o+DsF4MpdOuK2Bxxz7+0/iwjlQAeT1DE7okbFgPoI
+zbWFs5QgVQ/s9zJg1nod0W3jOG4qfimyCBgWw3pnVleMMoDyZ9oEGUO1r+G2ZXDW
+p/rcB3TmLNgCYGQlOFfUp1SBi+vJLuK+1q4h7f9yfSesInMaZlT72QhpPWxe/zQM
+4t57d5tHMyDe6FBn1WK7J+x6N1qZ7luzaoMaBufTLnSwoqRe52y39p53fMi7Mcj0
+AwIDAQAB
+-----END PUBLIC KEY-----`
+
+// Distinct error codes so callers can tell why a license was rejected
+// instead of getting one generic "invalid license" response.
+var (
+	ErrInvalidSignature = errors.New("licensing: invalid signature")
+	ErrExpired          = errors.New("licensing: license expired")
+	ErrNotYetValid      = errors.New("licensing: license not yet valid")
+	ErrDuplicateJTI     = errors.New("licensing: license already installed")
+	ErrNotFound         = errors.New("licensing: license not found")
+)
+
+// Claims are the fields LunarDB license tokens must carry, on top of the
+// standard registered JWT claims (jti, exp, nbf).
+type Claims struct {
+	jwt.RegisteredClaims
+	FeatureSet []string `json:"feature_set"`
+	MaxAPIKeys int      `json:"max_api_keys"`
+	AccountID  string   `json:"account_id"`
+}
+
+// License is the stored, already-verified representation of an installed
+// license token.
+type License struct {
+	JTI        string    `json:"jti"`
+	AccountID  string    `json:"account_id"`
+	FeatureSet []string  `json:"feature_set"`
+	MaxAPIKeys int       `json:"max_api_keys"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	NotBefore  time.Time `json:"not_before"`
+	Token      string    `json:"token"`
+}
+
+// HasFeature reports whether this license grants the named feature and is
+// currently within its validity window. A license installed without an exp
+// claim has a zero ExpiresAt and does not expire.
+func (l *License) HasFeature(feature string) bool {
+	now := time.Now()
+	if (!l.ExpiresAt.IsZero() && now.After(l.ExpiresAt)) || now.Before(l.NotBefore) {
+		return false
+	}
+	for _, f := range l.FeatureSet {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// storeKeyPrefix namespaces license records inside the KV store so they
+// don't collide with regular key/value data.
+const storeKeyPrefix = "__license:"
+
+// KVStore is the subset of LunarDB's storage API the licensing subsystem
+// needs to persist licenses. *api/v1's LunarDB already satisfies this.
+type KVStore interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+	Del(key string) bool
+	Keys() []string
+}
+
+// Manager verifies license tokens against the embedded public key and
+// persists accepted licenses in a KVStore.
+type Manager struct {
+	store     KVStore
+	publicKey *rsa.PublicKey
+	mu        sync.Mutex
+}
+
+// NewManager builds a Manager backed by store, parsing the embedded
+// verification key once at construction time.
+func NewManager(store KVStore) (*Manager, error) {
+	block, _ := pem.Decode([]byte(embeddedPublicKeyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("licensing: embedded public key is not valid PEM")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("licensing: parse embedded public key: %w", err)
+	}
+	rsaPub, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("licensing: embedded public key is not RSA")
+	}
+	return &Manager{store: store, publicKey: rsaPub}, nil
+}
+
+// Install verifies tokenString and, if valid and not previously installed,
+// persists it and returns the resulting License.
+func (m *Manager) Install(tokenString string) (*License, error) {
+	var claims Claims
+	token, err := jwt.ParseWithClaims(tokenString, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, ErrInvalidSignature
+		}
+		return m.publicKey, nil
+	}, jwt.WithoutClaimsValidation())
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidSignature
+	}
+
+	now := time.Now()
+	if claims.ExpiresAt != nil && now.After(claims.ExpiresAt.Time) {
+		return nil, ErrExpired
+	}
+	if claims.NotBefore != nil && now.Before(claims.NotBefore.Time) {
+		return nil, ErrNotYetValid
+	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("licensing: token missing jti claim")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := storeKeyPrefix + claims.ID
+	if _, exists := m.store.Get(key); exists {
+		return nil, ErrDuplicateJTI
+	}
+
+	license := &License{
+		JTI:        claims.ID,
+		AccountID:  claims.AccountID,
+		FeatureSet: claims.FeatureSet,
+		MaxAPIKeys: claims.MaxAPIKeys,
+		Token:      tokenString,
+	}
+	if claims.ExpiresAt != nil {
+		license.ExpiresAt = claims.ExpiresAt.Time
+	}
+	if claims.NotBefore != nil {
+		license.NotBefore = claims.NotBefore.Time
+	}
+
+	encoded, err := json.Marshal(license)
+	if err != nil {
+		return nil, fmt.Errorf("licensing: encode license: %w", err)
+	}
+	m.store.Set(key, string(encoded))
+
+	return license, nil
+}
+
+// Get returns the installed license with the given jti, if any.
+func (m *Manager) Get(jti string) (*License, error) {
+	raw, exists := m.store.Get(storeKeyPrefix + jti)
+	if !exists {
+		return nil, ErrNotFound
+	}
+	var license License
+	if err := json.Unmarshal([]byte(raw), &license); err != nil {
+		return nil, fmt.Errorf("licensing: decode license: %w", err)
+	}
+	return &license, nil
+}
+
+// Delete removes the installed license with the given jti, reporting
+// whether it existed.
+func (m *Manager) Delete(jti string) bool {
+	return m.store.Del(storeKeyPrefix + jti)
+}
+
+// List returns every currently installed license.
+func (m *Manager) List() ([]*License, error) {
+	var licenses []*License
+	for _, key := range m.store.Keys() {
+		if len(key) <= len(storeKeyPrefix) || key[:len(storeKeyPrefix)] != storeKeyPrefix {
+			continue
+		}
+		raw, exists := m.store.Get(key)
+		if !exists {
+			continue
+		}
+		var license License
+		if err := json.Unmarshal([]byte(raw), &license); err != nil {
+			return nil, fmt.Errorf("licensing: decode license %q: %w", key, err)
+		}
+		licenses = append(licenses, &license)
+	}
+	return licenses, nil
+}
+
+// HasFeature reports whether any currently installed, non-expired license
+// grants the named feature.
+func (m *Manager) HasFeature(feature string) bool {
+	licenses, err := m.List()
+	if err != nil {
+		return false
+	}
+	for _, license := range licenses {
+		if license.HasFeature(feature) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,59 +4,65 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/Kazooki123/lunardb_api/internal/auth/keys"
 	"github.com/golang-jwt/jwt/v5"
 )
 
-// AuthMiddleware handles JWT authentication for protected routes
-func AuthMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		authHeader := r.Header.Get("Authorization")
-		if authHeader == "" {
-			http.Error(w, "Authorization header required", http.StatusUnauthorized)
-			return
-		}
-
-		bearerToken := strings.Split(authHeader, " ")
-		if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
-			http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
-			return
-		}
-
-		tokenString := bearerToken[1]
-
-		// Parse and validate JWT token
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			// Verify signing method
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				return nil, jwt.ErrSignatureInvalid
+// AuthMiddleware handles JWT authentication for protected routes, resolving
+// the verification key for each token via its "kid" header so tokens signed
+// with an older, now-retired key still validate during a rotation window.
+//
+// Not currently wired into any gin route: api/v1's routes are gated by
+// APIKeyMiddleware/AdminKeyMiddleware (a static API key set) instead of
+// per-user JWTs. AuthMiddleware and GenerateToken exist for a caller that
+// wants per-user bearer tokens backed by the keys.Manager rotation scheme
+// above; nothing in this repo is that caller yet.
+func AuthMiddleware(manager *keys.Manager) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			authHeader := r.Header.Get("Authorization")
+			if authHeader == "" {
+				http.Error(w, "Authorization header required", http.StatusUnauthorized)
+				return
 			}
-			return []byte("XXXXXXXXXXXXXXXX"), nil
-		})
 
-		if err != nil {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			bearerToken := strings.Split(authHeader, " ")
+			if len(bearerToken) != 2 || bearerToken[0] != "Bearer" {
+				http.Error(w, "Invalid authorization format", http.StatusUnauthorized)
+				return
+			}
 
-		if !token.Valid {
-			http.Error(w, "Invalid token", http.StatusUnauthorized)
-			return
-		}
+			tokenString := bearerToken[1]
 
-		next.ServeHTTP(w, r)
-	})
+			token, err := jwt.Parse(tokenString, manager.Keyfunc)
+			if err != nil || !token.Valid {
+				http.Error(w, "Invalid token", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
 }
 
-func GenerateToken(userID string) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
+// GenerateToken signs a new JWT for userID with the key manager's current
+// active key, tagging the token header with that key's kid so verification
+// can find it later even after a rotation.
+func GenerateToken(manager *keys.Manager, userID string) (string, error) {
+	key, err := manager.ActiveKey()
+	if err != nil {
+		return "", err
+	}
 
-	claims["user_id"] = userID
+	token := jwt.NewWithClaims(key.SigningMethod(), jwt.MapClaims{
+		"user_id": userID,
+	})
+	token.Header["kid"] = key.ID
 
-	tokenString, err := token.SignedString([]byte("003r3wg39t98g398gf")) // Just a placeholder, not a real key.
+	signingKey, err := key.SigningKey()
 	if err != nil {
 		return "", err
 	}
 
-	return tokenString, nil
+	return token.SignedString(signingKey)
 }
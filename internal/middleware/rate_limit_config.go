@@ -0,0 +1,90 @@
+package middleware
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// rateLimitConfigFile mirrors the on-disk YAML shape operators write, e.g.:
+//
+//	routes:
+//	  - pattern: /api/v1/set
+//	    extractor: client.ip
+//	    rulesets:
+//	      - name: burst
+//	        period: 3s
+//	        average: 5
+//	        burst: 10
+//	      - name: sustained
+//	        period: 10s
+//	        average: 100
+//	        burst: 200
+//	  - pattern: /api/v1/keys
+//	    extractor: header:X-API-Key
+//	    rulesets:
+//	      - name: default
+//	        period: 1s
+//	        average: 20
+//	        burst: 40
+type rateLimitConfigFile struct {
+	Routes []rateLimitRouteConfig `yaml:"routes"`
+}
+
+type rateLimitRouteConfig struct {
+	Pattern   string                `yaml:"pattern"`
+	Extractor string                `yaml:"extractor"`
+	Rulesets  []rateLimitRuleConfig `yaml:"rulesets"`
+}
+
+type rateLimitRuleConfig struct {
+	Name    string `yaml:"name"`
+	Period  string `yaml:"period"`
+	Average int    `yaml:"average"`
+	Burst   int    `yaml:"burst"`
+}
+
+// LoadRateLimitConfig reads a YAML file mapping route patterns to rate-limit
+// rulesets and returns the RouteRules consumed by NewIPRateLimiterFromRoutes.
+func LoadRateLimitConfig(path string) ([]RouteRules, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("middleware: read rate limit config: %w", err)
+	}
+
+	var file rateLimitConfigFile
+	if err := yaml.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("middleware: parse rate limit config: %w", err)
+	}
+
+	routes := make([]RouteRules, 0, len(file.Routes))
+	for _, routeCfg := range file.Routes {
+		if routeCfg.Pattern == "" {
+			return nil, fmt.Errorf("middleware: rate limit config: route missing pattern")
+		}
+
+		rulesets := make([]Ruleset, 0, len(routeCfg.Rulesets))
+		for _, ruleCfg := range routeCfg.Rulesets {
+			period, err := time.ParseDuration(ruleCfg.Period)
+			if err != nil {
+				return nil, fmt.Errorf("middleware: rate limit config: route %q ruleset %q: %w", routeCfg.Pattern, ruleCfg.Name, err)
+			}
+			rulesets = append(rulesets, Ruleset{
+				Name:    ruleCfg.Name,
+				Period:  period,
+				Average: ruleCfg.Average,
+				Burst:   ruleCfg.Burst,
+			})
+		}
+
+		routes = append(routes, RouteRules{
+			Pattern:   routeCfg.Pattern,
+			Extractor: routeCfg.Extractor,
+			Rulesets:  rulesets,
+		})
+	}
+
+	return routes, nil
+}
@@ -1,104 +1,257 @@
 package middleware
 
 import (
+	"fmt"
 	"net/http"
 	"sync"
 	"time"
+
 	"golang.org/x/time/rate"
 )
 
-// Client holds the rate limiter for each visitor and the last time the visitor was seen
+// KeyExtractor pulls the value a rate-limit ruleset should be keyed on out of
+// a request, e.g. the caller's IP, an API key header, or the request host.
+type KeyExtractor func(r *http.Request) string
+
+// ClientIPExtractor keys on the caller's remote address, honoring
+// X-Forwarded-For when present (reverse-proxied deployments).
+func ClientIPExtractor(r *http.Request) string {
+	if forwardedIP := r.Header.Get("X-Forwarded-For"); forwardedIP != "" {
+		return forwardedIP
+	}
+	return r.RemoteAddr
+}
+
+// HeaderExtractor builds a KeyExtractor that keys on the value of a single
+// request header, e.g. "X-API-Key".
+func HeaderExtractor(header string) KeyExtractor {
+	return func(r *http.Request) string {
+		return r.Header.Get(header)
+	}
+}
+
+// HostExtractor keys on the request's Host field.
+func HostExtractor(r *http.Request) string {
+	return r.Host
+}
+
+// ExtractorByName maps the config-file names operators use ("client.ip",
+// "header:X-API-Key", "request.host") to a concrete KeyExtractor.
+func ExtractorByName(name string) (KeyExtractor, error) {
+	if name == "client.ip" || name == "" {
+		return ClientIPExtractor, nil
+	}
+	if name == "request.host" {
+		return HostExtractor, nil
+	}
+	if len(name) > len("header:") && name[:len("header:")] == "header:" {
+		return HeaderExtractor(name[len("header:"):]), nil
+	}
+	return nil, fmt.Errorf("middleware: unknown extractor %q", name)
+}
+
+// Ruleset describes a single rate-limit window, e.g. "5 req / 3s burst 10".
+type Ruleset struct {
+	Name    string
+	Period  time.Duration
+	Average int
+	Burst   int
+}
+
+// limit converts the ruleset's period/average into a token-bucket rate.
+func (rs Ruleset) limit() rate.Limit {
+	if rs.Period <= 0 || rs.Average <= 0 {
+		return rate.Inf
+	}
+	return rate.Every(rs.Period / time.Duration(rs.Average))
+}
+
+// RouteRules binds a route pattern to the extractor and rulesets that apply
+// to it. A request matching the route must pass every ruleset to be allowed.
+type RouteRules struct {
+	Pattern   string
+	Extractor string // name resolved via ExtractorByName
+	Rulesets  []Ruleset
+}
+
+// Client holds one rate.Limiter per configured ruleset for a given
+// (extracted key, route pattern) pair, plus the last time it was seen.
 type Client struct {
-	limiter  *rate.Limiter
+	limiters map[string]*rate.Limiter // ruleset name -> limiter
 	lastSeen time.Time
 }
 
-// IPRateLimiter manages rate limiting for different IP addresses
+// IPRateLimiter manages rate limiting for different clients, applying
+// whichever rulesets are configured for the matched route.
 type IPRateLimiter struct {
 	clients    map[string]*Client
 	mu         sync.RWMutex
-	rate       rate.Limit
-	burst      int
+	routes     []RouteRules
 	expiration time.Duration
 }
 
-// NewIPRateLimiter creates a new rate limiter instance
+// NewIPRateLimiter creates a rate limiter instance with a single default
+// ruleset, preserving the old single-window behavior for callers that don't
+// need per-route rulesets.
 func NewIPRateLimiter(r rate.Limit, b int) *IPRateLimiter {
+	average := int(r)
+	if average <= 0 {
+		average = 1
+	}
+	return NewIPRateLimiterFromRoutes([]RouteRules{
+		{
+			Pattern:   "*",
+			Extractor: "client.ip",
+			Rulesets: []Ruleset{
+				{Name: "default", Period: time.Second, Average: average, Burst: b},
+			},
+		},
+	})
+}
+
+// NewIPRateLimiterFromRoutes creates a rate limiter driven by a set of
+// per-route rulesets, typically produced by LoadRateLimitConfig.
+func NewIPRateLimiterFromRoutes(routes []RouteRules) *IPRateLimiter {
 	return &IPRateLimiter{
 		clients:    make(map[string]*Client),
-		rate:       r,
-		burst:      b,
-		expiration: 1 * time.Hour, // Cleanup unused IPs after 1 hour
+		routes:     routes,
+		expiration: 1 * time.Hour, // Cleanup unused clients after 1 hour
 	}
 }
 
-// AddClient creates a new rate limiter for a client IP
-func (rl *IPRateLimiter) AddClient(ip string) *rate.Limiter {
-	limiter := rate.NewLimiter(rl.rate, rl.burst)
-	rl.mu.Lock()
-	rl.clients[ip] = &Client{
-		limiter:  limiter,
-		lastSeen: time.Now(),
+// matchRoute returns the RouteRules whose pattern equals routePattern
+// exactly, falling back to the catch-all "*" entry if present. Patterns are
+// compared as plain strings; no wildcard or prefix matching is done.
+func (rl *IPRateLimiter) matchRoute(routePattern string) (RouteRules, bool) {
+	var fallback RouteRules
+	haveFallback := false
+	for _, route := range rl.routes {
+		if route.Pattern == routePattern {
+			return route, true
+		}
+		if route.Pattern == "*" {
+			fallback = route
+			haveFallback = true
+		}
 	}
-	rl.mu.Unlock()
-	return limiter
+	return fallback, haveFallback
 }
 
-// GetLimiter returns the rate limiter for a client IP
-func (rl *IPRateLimiter) GetLimiter(ip string) *rate.Limiter {
+// clientFor returns (creating if necessary) the Client tracking key+route,
+// seeded with one limiter per ruleset declared for that route.
+func (rl *IPRateLimiter) clientFor(key, routePattern string, rulesets []Ruleset) *Client {
+	cacheKey := routePattern + "|" + key
+
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	client, exists := rl.clients[ip]
+	client, exists := rl.clients[cacheKey]
 	if !exists {
-		return rl.AddClient(ip)
+		client = &Client{limiters: make(map[string]*rate.Limiter, len(rulesets))}
+		for _, rs := range rulesets {
+			client.limiters[rs.Name] = rate.NewLimiter(rs.limit(), rs.Burst)
+		}
+		rl.clients[cacheKey] = client
 	}
-
-	// Update last seen time
 	client.lastSeen = time.Now()
-	return client.limiter
+	return client
 }
 
-// CleanupStaleClients removes rate limiters for IPs that haven't been seen for a while
+// CleanupStaleClients removes rate limiters for clients that haven't been
+// seen for a while.
 func (rl *IPRateLimiter) CleanupStaleClients() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	for ip, client := range rl.clients {
+	for key, client := range rl.clients {
 		if time.Since(client.lastSeen) > rl.expiration {
-			delete(rl.clients, ip)
+			delete(rl.clients, key)
 		}
 	}
 }
 
-// Create a global rate limiter instance
-var limiter = NewIPRateLimiter(1, 5) // 1 request per second with burst of 5
+// Decision reports the outcome of checking every ruleset bound to a route.
+// When Allowed is false, RetryAfter/Limit/Remaining/Reset come from whichever
+// ruleset had the shortest wait, so the caller can surface Retry-After and
+// X-RateLimit-* headers.
+type Decision struct {
+	Allowed    bool
+	RetryAfter time.Duration
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RejectedBy string
+}
 
-// RateLimitMiddleware is the middleware function to limit requests by IP
-func RateLimitMiddleware(next http.Handler) http.Handler {
-	go func() {
-		for {
-			time.Sleep(time.Hour)
-			limiter.CleanupStaleClients()
+// Allow runs every ruleset configured for routePattern against the request,
+// keyed by whatever extractor that route declares. All rulesets must pass.
+func (rl *IPRateLimiter) Allow(r *http.Request, routePattern string) (Decision, error) {
+	route, ok := rl.matchRoute(routePattern)
+	if !ok || len(route.Rulesets) == 0 {
+		return Decision{Allowed: true}, nil
+	}
+
+	extractor, err := ExtractorByName(route.Extractor)
+	if err != nil {
+		return Decision{}, err
+	}
+	key := extractor(r)
+
+	client := rl.clientFor(key, routePattern, route.Rulesets)
+
+	now := time.Now()
+
+	// Reserve from every ruleset before deciding anything: if we cancelled
+	// only the reservation that failed, rulesets with headroom would still
+	// have a token committed against them, starving their budget for
+	// traffic that never actually got through.
+	reservations := make([]*rate.Reservation, len(route.Rulesets))
+	for i, rs := range route.Rulesets {
+		reservations[i] = client.limiters[rs.Name].ReserveN(now, 1)
+	}
+
+	decision := Decision{Allowed: true}
+	shortestWait := time.Duration(-1)
+
+	for i, rs := range route.Rulesets {
+		reservation := reservations[i]
+		if !reservation.OK() {
+			continue
 		}
-	}()
+		if wait := reservation.DelayFrom(now); wait > 0 {
+			decision.Allowed = false
+			if shortestWait == -1 || wait < shortestWait {
+				shortestWait = wait
+				decision.RetryAfter = wait
+				decision.Limit = rs.Burst
+				decision.Remaining = 0
+				decision.Reset = wait
+				decision.RejectedBy = rs.Name
+			}
+		}
+	}
 
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Get IP address from request
-		ip := r.RemoteAddr
-		// For production, you might want to handle X-Forwarded-For or X-Real-IP headers
-		if forwardedIP := r.Header.Get("X-Forwarded-For"); forwardedIP != "" {
-			ip = forwardedIP
+	if !decision.Allowed {
+		for _, reservation := range reservations {
+			if reservation.OK() {
+				reservation.CancelAt(now)
+			}
 		}
+	}
 
-		// Get rate limiter for this IP
-		limiter := limiter.GetLimiter(ip)
+	return decision, nil
+}
 
-		if !limiter.Allow() {
-			http.Error(w, "Rate limit exceeded", http.StatusTooManyRequests)
-			return
+// StartCleanup runs CleanupStaleClients in the background every interval for
+// the lifetime of the process. Callers should invoke this once, right after
+// constructing the limiter, regardless of whether it ends up wired in as gin
+// middleware or behind a chain.Interceptor — otherwise rl.clients grows by
+// one entry per distinct (key, route) pair forever.
+func (rl *IPRateLimiter) StartCleanup(interval time.Duration) {
+	go func() {
+		for {
+			time.Sleep(interval)
+			rl.CleanupStaleClients()
 		}
-
-		next.ServeHTTP(w, r)
-	})
+	}()
 }
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// TestIPRateLimiterAllowCancelsHeadroomOnRejection exercises the reserve-then-
+// cancel accounting in Allow: when one ruleset rejects a request, every
+// other ruleset's reservation for that same request must be given back, not
+// silently consumed. Otherwise a ruleset with headroom starves its own
+// budget on traffic that never actually got through.
+func TestIPRateLimiterAllowCancelsHeadroomOnRejection(t *testing.T) {
+	routes := []RouteRules{
+		{
+			Pattern:   "/test",
+			Extractor: "client.ip",
+			Rulesets: []Ruleset{
+				// Deliberately tight: one request every 30ms, no burst.
+				{Name: "tight", Period: 30 * time.Millisecond, Average: 1, Burst: 1},
+				// Deliberately loose: burst of 2, long refill period, so
+				// across this test it only runs out if a cancelled
+				// reservation was wrongly kept.
+				{Name: "loose", Period: time.Second, Average: 2, Burst: 2},
+			},
+		},
+	}
+	limiter := NewIPRateLimiterFromRoutes(routes)
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.RemoteAddr = "10.0.0.1:1234"
+
+	first, err := limiter.Allow(req, "/test")
+	if err != nil {
+		t.Fatalf("Allow (1st): %v", err)
+	}
+	if !first.Allowed {
+		t.Fatalf("Allow (1st) = rejected by %q, want allowed", first.RejectedBy)
+	}
+
+	second, err := limiter.Allow(req, "/test")
+	if err != nil {
+		t.Fatalf("Allow (2nd): %v", err)
+	}
+	if second.Allowed || second.RejectedBy != "tight" {
+		t.Fatalf("Allow (2nd) = allowed=%v rejectedBy=%q, want rejected by \"tight\"", second.Allowed, second.RejectedBy)
+	}
+
+	time.Sleep(35 * time.Millisecond) // let "tight" refill its single token
+
+	third, err := limiter.Allow(req, "/test")
+	if err != nil {
+		t.Fatalf("Allow (3rd): %v", err)
+	}
+	if !third.Allowed {
+		// If the 2nd call's rejected request had NOT cancelled its "loose"
+		// reservation, "loose" would already be at its burst of 2 here and
+		// this call would be rejected by "loose" instead.
+		t.Fatalf("Allow (3rd) = rejected by %q, want allowed (loose ruleset's reservation from the rejected 2nd call should have been cancelled)", third.RejectedBy)
+	}
+}
+
+func TestIPRateLimiterAllowNoRulesetsForRoute(t *testing.T) {
+	limiter := NewIPRateLimiterFromRoutes(nil)
+	req := httptest.NewRequest(http.MethodGet, "/unconfigured", nil)
+
+	decision, err := limiter.Allow(req, "/unconfigured")
+	if err != nil {
+		t.Fatalf("Allow: %v", err)
+	}
+	if !decision.Allowed {
+		t.Errorf("Allow with no matching route rules = rejected, want allowed (no rulesets to enforce)")
+	}
+}
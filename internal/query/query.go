@@ -0,0 +1,235 @@
+// Package query implements the small query DSL and planner backing the
+// /api/v1/query endpoint, e.g.
+//
+//	{"from":"users","where":{"age":{"$gt":21}},"limit":50,"sort":["-created_at"]}
+package query
+
+import (
+	"encoding/base64"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/Kazooki123/lunardb_api/internal/schema"
+)
+
+// Record is one namespaced record: its ID (the part of the key after the
+// namespace prefix) plus its decoded fields.
+type Record struct {
+	ID     string                 `json:"id"`
+	Fields map[string]interface{} `json:"fields"`
+}
+
+// Condition is a single field's filter, e.g. {"$gt": 21}. Supported
+// operators: $eq, $ne, $gt, $gte, $lt, $lte.
+type Condition map[string]interface{}
+
+// Query is the decoded request body for POST /api/v1/query.
+type Query struct {
+	From   string               `json:"from"`
+	Where  map[string]Condition `json:"where"`
+	Limit  int                  `json:"limit"`
+	Sort   []string             `json:"sort"`
+	Cursor string               `json:"cursor"`
+}
+
+// Source supplies the records a Query plans over. ScanAll is the full-scan
+// path; FetchByIDs lets the planner avoid scanning when an index narrows
+// the candidate set to specific IDs.
+type Source interface {
+	ScanAll() ([]Record, error)
+	FetchByIDs(ids []string) ([]Record, error)
+}
+
+// Result is the paginated outcome of executing a Query.
+type Result struct {
+	Records    []Record `json:"records"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+const defaultLimit = 50
+
+// Execute plans and runs q against source, using reg's declared indexes
+// when q.Where narrows to a single indexed equality filter and falling back
+// to a full scan otherwise.
+func Execute(reg *schema.Registry, source Source, q Query) (Result, error) {
+	records, err := candidates(reg, source, q)
+	if err != nil {
+		return Result{}, err
+	}
+
+	filtered := make([]Record, 0, len(records))
+	for _, record := range records {
+		if matches(record, q.Where) {
+			filtered = append(filtered, record)
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sortRecords(filtered, q.Sort)
+	}
+
+	offset := 0
+	if q.Cursor != "" {
+		offset, err = decodeCursor(q.Cursor)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = defaultLimit
+	}
+
+	if offset > len(filtered) {
+		offset = len(filtered)
+	}
+	end := offset + limit
+	if end > len(filtered) {
+		end = len(filtered)
+	}
+	page := filtered[offset:end]
+
+	result := Result{Records: page}
+	if end < len(filtered) {
+		result.NextCursor = encodeCursor(end)
+	}
+	return result, nil
+}
+
+// candidates returns the records the planner will filter, preferring an
+// indexed lookup over source.ScanAll when possible.
+func candidates(reg *schema.Registry, source Source, q Query) ([]Record, error) {
+	if field, value, ok := singleEqualityFilter(q.Where); ok {
+		for _, indexed := range reg.IndexedFields(q.From) {
+			if indexed != field {
+				continue
+			}
+			ids, isIndexed := reg.IndexLookup(q.From, field, value)
+			if isIndexed {
+				return source.FetchByIDs(ids)
+			}
+		}
+	}
+	return source.ScanAll()
+}
+
+// singleEqualityFilter reports whether where is exactly one field with only
+// an $eq condition, the shape the index lookup can serve directly.
+func singleEqualityFilter(where map[string]Condition) (field, value string, ok bool) {
+	if len(where) != 1 {
+		return "", "", false
+	}
+	for f, cond := range where {
+		if len(cond) != 1 {
+			return "", "", false
+		}
+		eq, hasEq := cond["$eq"]
+		if !hasEq {
+			return "", "", false
+		}
+		return f, fmt.Sprintf("%v", eq), true
+	}
+	return "", "", false
+}
+
+func matches(record Record, where map[string]Condition) bool {
+	for field, cond := range where {
+		value, present := record.Fields[field]
+		if !present {
+			return false
+		}
+		for op, operand := range cond {
+			if !matchesOp(value, op, operand) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func matchesOp(value interface{}, op string, operand interface{}) bool {
+	switch op {
+	case "$eq":
+		return fmt.Sprintf("%v", value) == fmt.Sprintf("%v", operand)
+	case "$ne":
+		return fmt.Sprintf("%v", value) != fmt.Sprintf("%v", operand)
+	case "$gt", "$gte", "$lt", "$lte":
+		v, vOK := toFloat(value)
+		o, oOK := toFloat(operand)
+		if !vOK || !oOK {
+			return false
+		}
+		switch op {
+		case "$gt":
+			return v > o
+		case "$gte":
+			return v >= o
+		case "$lt":
+			return v < o
+		default:
+			return v <= o
+		}
+	default:
+		return false
+	}
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	}
+	return 0, false
+}
+
+func sortRecords(records []Record, fields []string) {
+	sort.SliceStable(records, func(i, j int) bool {
+		for _, field := range fields {
+			desc := strings.HasPrefix(field, "-")
+			name := strings.TrimPrefix(field, "-")
+
+			a, aOK := toFloat(records[i].Fields[name])
+			b, bOK := toFloat(records[j].Fields[name])
+			if !aOK || !bOK {
+				as := fmt.Sprintf("%v", records[i].Fields[name])
+				bs := fmt.Sprintf("%v", records[j].Fields[name])
+				if as == bs {
+					continue
+				}
+				if desc {
+					return as > bs
+				}
+				return as < bs
+			}
+			if a == b {
+				continue
+			}
+			if desc {
+				return a > b
+			}
+			return a < b
+		}
+		return false
+	})
+}
+
+func encodeCursor(offset int) string {
+	return base64.URLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	raw, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("query: invalid cursor: %w", err)
+	}
+	offset, err := strconv.Atoi(string(raw))
+	if err != nil {
+		return 0, fmt.Errorf("query: invalid cursor: %w", err)
+	}
+	return offset, nil
+}
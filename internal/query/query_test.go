@@ -0,0 +1,124 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/Kazooki123/lunardb_api/internal/schema"
+)
+
+// fakeStore is a minimal in-memory schema.KVStore for tests.
+type fakeStore struct {
+	data map[string]string
+}
+
+func newFakeStore() *fakeStore { return &fakeStore{data: make(map[string]string)} }
+
+func (s *fakeStore) Get(key string) (string, bool) { v, ok := s.data[key]; return v, ok }
+func (s *fakeStore) Set(key, value string)         { s.data[key] = value }
+func (s *fakeStore) Keys() []string {
+	keys := make([]string, 0, len(s.data))
+	for k := range s.data {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// trackingSource is a Source that records which path the planner took.
+type trackingSource struct {
+	records        []Record
+	scanCalls      int
+	fetchByIDCalls int
+}
+
+func (s *trackingSource) ScanAll() ([]Record, error) {
+	s.scanCalls++
+	return s.records, nil
+}
+
+func (s *trackingSource) FetchByIDs(ids []string) ([]Record, error) {
+	s.fetchByIDCalls++
+	byID := make(map[string]Record, len(s.records))
+	for _, r := range s.records {
+		byID[r.ID] = r
+	}
+	out := make([]Record, 0, len(ids))
+	for _, id := range ids {
+		if r, ok := byID[id]; ok {
+			out = append(out, r)
+		}
+	}
+	return out, nil
+}
+
+func newIndexedRegistry(t *testing.T) *schema.Registry {
+	t.Helper()
+	reg := schema.NewRegistry(newFakeStore())
+	err := reg.Register(schema.Definition{
+		Namespace: "users",
+		Fields: []schema.FieldDef{
+			{Name: "email", Type: schema.TypeString, Index: true},
+			{Name: "bio", Type: schema.TypeString},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	reg.IndexUpdate("users", "email", "a@example.com", "1")
+	reg.IndexUpdate("users", "email", "b@example.com", "2")
+	return reg
+}
+
+func TestCandidatesUsesIndexForSingleEqualityFilter(t *testing.T) {
+	reg := newIndexedRegistry(t)
+	source := &trackingSource{records: []Record{
+		{ID: "1", Fields: map[string]interface{}{"email": "a@example.com"}},
+		{ID: "2", Fields: map[string]interface{}{"email": "b@example.com"}},
+	}}
+
+	q := Query{From: "users", Where: map[string]Condition{"email": {"$eq": "a@example.com"}}}
+	result, err := Execute(reg, source, q)
+	if err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if source.fetchByIDCalls != 1 || source.scanCalls != 0 {
+		t.Errorf("fetchByIDCalls=%d scanCalls=%d, want an indexed FetchByIDs and no ScanAll", source.fetchByIDCalls, source.scanCalls)
+	}
+	if len(result.Records) != 1 || result.Records[0].ID != "1" {
+		t.Errorf("Records = %+v, want exactly record 1", result.Records)
+	}
+}
+
+func TestCandidatesFallsBackToScanForUnindexedField(t *testing.T) {
+	reg := newIndexedRegistry(t)
+	source := &trackingSource{records: []Record{
+		{ID: "1", Fields: map[string]interface{}{"bio": "hello"}},
+	}}
+
+	q := Query{From: "users", Where: map[string]Condition{"bio": {"$eq": "hello"}}}
+	if _, err := Execute(reg, source, q); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if source.scanCalls != 1 || source.fetchByIDCalls != 0 {
+		t.Errorf("scanCalls=%d fetchByIDCalls=%d, want a full scan for a field with no declared index", source.scanCalls, source.fetchByIDCalls)
+	}
+}
+
+func TestCandidatesFallsBackToScanForNonEqualityFilter(t *testing.T) {
+	reg := newIndexedRegistry(t)
+	source := &trackingSource{records: []Record{
+		{ID: "1", Fields: map[string]interface{}{"email": "a@example.com"}},
+	}}
+
+	// $eq alone is servable from the index; $gt is not, even on an indexed
+	// field, so this must still fall back to a scan.
+	q := Query{From: "users", Where: map[string]Condition{"email": {"$gt": "a@example.com"}}}
+	if _, err := Execute(reg, source, q); err != nil {
+		t.Fatalf("Execute: %v", err)
+	}
+
+	if source.scanCalls != 1 || source.fetchByIDCalls != 0 {
+		t.Errorf("scanCalls=%d fetchByIDCalls=%d, want a full scan for a non-equality filter", source.scanCalls, source.fetchByIDCalls)
+	}
+}
@@ -0,0 +1,306 @@
+// Package schema registers per-namespace field definitions for LunarDB
+// records and validates writes against them.
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// FieldType is the type a schema field's values must have.
+type FieldType string
+
+const (
+	TypeString FieldType = "string"
+	TypeInt    FieldType = "int"
+	TypeFloat  FieldType = "float"
+	TypeBool   FieldType = "bool"
+)
+
+// FieldDef describes one field of a namespace's schema.
+type FieldDef struct {
+	Name     string    `json:"name"`
+	Type     FieldType `json:"type"`
+	Required bool      `json:"required"`
+	Index    bool      `json:"index"`
+	Unique   bool      `json:"unique"`
+}
+
+// Definition is a namespace's registered schema.
+type Definition struct {
+	Namespace string     `json:"namespace"`
+	Fields    []FieldDef `json:"fields"`
+}
+
+// FieldError reports why a single field failed validation.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+const storeKeyPrefix = "__schema:"
+
+// KVStore is the subset of LunarDB's storage API used to persist schema
+// definitions.
+type KVStore interface {
+	Get(key string) (string, bool)
+	Set(key, value string)
+	Keys() []string
+}
+
+// Registry holds every namespace's registered schema plus the in-memory
+// secondary indexes the query planner uses to avoid full scans.
+type Registry struct {
+	store KVStore
+
+	mu      sync.RWMutex
+	schemas map[string]*Definition
+	// indexes[namespace][field][value] -> set of record IDs
+	indexes map[string]map[string]map[string]map[string]struct{}
+}
+
+// NewRegistry creates a Registry backed by store, loading any schemas
+// persisted from a previous run.
+func NewRegistry(store KVStore) *Registry {
+	r := &Registry{
+		store:   store,
+		schemas: make(map[string]*Definition),
+		indexes: make(map[string]map[string]map[string]map[string]struct{}),
+	}
+	r.loadPersisted()
+	return r
+}
+
+func (r *Registry) loadPersisted() {
+	for _, key := range r.store.Keys() {
+		if !strings.HasPrefix(key, storeKeyPrefix) {
+			continue
+		}
+		raw, ok := r.store.Get(key)
+		if !ok {
+			continue
+		}
+		var def Definition
+		if err := json.Unmarshal([]byte(raw), &def); err != nil {
+			continue
+		}
+		r.schemas[def.Namespace] = &def
+		r.indexes[def.Namespace] = make(map[string]map[string]map[string]struct{})
+	}
+	r.rebuildIndexes()
+}
+
+// rebuildIndexes replays every already-stored "namespace/id" record for each
+// registered namespace through IndexUpdate. Without this, a durable backend
+// (chunk0-4's AOF/embedded-KV engines) restarts with r.indexes empty even
+// though the records it describes are still in the store, so HasUnique
+// would silently let a restart-spanning duplicate through.
+func (r *Registry) rebuildIndexes() {
+	for namespace := range r.schemas {
+		fields := r.IndexedFields(namespace)
+		if len(fields) == 0 {
+			continue
+		}
+
+		prefix := namespace + "/"
+		for _, key := range r.store.Keys() {
+			if !strings.HasPrefix(key, prefix) {
+				continue
+			}
+			raw, ok := r.store.Get(key)
+			if !ok {
+				continue
+			}
+			var record map[string]interface{}
+			if err := json.Unmarshal([]byte(raw), &record); err != nil {
+				continue
+			}
+
+			id := key[len(prefix):]
+			for _, field := range fields {
+				if value, ok := record[field]; ok {
+					r.IndexUpdate(namespace, field, fmt.Sprintf("%v", value), id)
+				}
+			}
+		}
+	}
+}
+
+// Register persists def, registering it for validation and indexing. A
+// second Register call for the same namespace replaces the schema; it does
+// not attempt to re-validate already-stored records.
+func (r *Registry) Register(def Definition) error {
+	if def.Namespace == "" {
+		return fmt.Errorf("schema: namespace is required")
+	}
+
+	encoded, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Errorf("schema: encode definition: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.schemas[def.Namespace] = &def
+	if _, ok := r.indexes[def.Namespace]; !ok {
+		r.indexes[def.Namespace] = make(map[string]map[string]map[string]struct{})
+	}
+	r.store.Set(storeKeyPrefix+def.Namespace, string(encoded))
+	return nil
+}
+
+// Get returns the schema registered for namespace, if any.
+func (r *Registry) Get(namespace string) (*Definition, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	def, ok := r.schemas[namespace]
+	return def, ok
+}
+
+// ValidateRecord checks record against namespace's schema, returning one
+// FieldError per violation of a required/type/unique constraint. uniqueCheck
+// is called only for fields marked Unique, to test whether value is already
+// taken by a different record ID.
+func (r *Registry) ValidateRecord(namespace, id string, record map[string]interface{}, uniqueCheck func(field, value string) bool) []FieldError {
+	def, ok := r.Get(namespace)
+	if !ok {
+		return nil
+	}
+
+	var errs []FieldError
+	for _, field := range def.Fields {
+		value, present := record[field.Name]
+
+		if !present || value == nil {
+			if field.Required {
+				errs = append(errs, FieldError{Field: field.Name, Message: "is required"})
+			}
+			continue
+		}
+
+		if !matchesType(value, field.Type) {
+			errs = append(errs, FieldError{Field: field.Name, Message: fmt.Sprintf("must be of type %s", field.Type)})
+			continue
+		}
+
+		if field.Unique && uniqueCheck != nil {
+			strValue := fmt.Sprintf("%v", value)
+			if uniqueCheck(field.Name, strValue) {
+				errs = append(errs, FieldError{Field: field.Name, Message: "must be unique"})
+			}
+		}
+	}
+	return errs
+}
+
+func matchesType(value interface{}, t FieldType) bool {
+	switch t {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeInt, TypeFloat:
+		_, ok := value.(float64) // JSON numbers decode as float64
+		return ok
+	case TypeBool:
+		_, ok := value.(bool)
+		return ok
+	default:
+		return true
+	}
+}
+
+// IndexedFields returns the names of namespace's fields marked Index (or
+// Unique, which implies an index).
+func (r *Registry) IndexedFields(namespace string) []string {
+	def, ok := r.Get(namespace)
+	if !ok {
+		return nil
+	}
+	var fields []string
+	for _, field := range def.Fields {
+		if field.Index || field.Unique {
+			fields = append(fields, field.Name)
+		}
+	}
+	return fields
+}
+
+// IndexUpdate records that record id has value for field in namespace,
+// replacing any previous value that id had for that field.
+func (r *Registry) IndexUpdate(namespace, field, value, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	byField, ok := r.indexes[namespace]
+	if !ok {
+		byField = make(map[string]map[string]map[string]struct{})
+		r.indexes[namespace] = byField
+	}
+	byValue, ok := byField[field]
+	if !ok {
+		byValue = make(map[string]map[string]struct{})
+		byField[field] = byValue
+	}
+
+	for _, ids := range byValue {
+		delete(ids, id)
+	}
+	if _, ok := byValue[value]; !ok {
+		byValue[value] = make(map[string]struct{})
+	}
+	byValue[value][id] = struct{}{}
+}
+
+// IndexDelete removes id from every index entry in namespace.
+func (r *Registry) IndexDelete(namespace, id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, byValue := range r.indexes[namespace] {
+		for _, ids := range byValue {
+			delete(ids, id)
+		}
+	}
+}
+
+// IndexLookup returns the record IDs namespace's field has recorded value
+// for, and whether that field is indexed at all.
+func (r *Registry) IndexLookup(namespace, field, value string) ([]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	byValue, ok := r.indexes[namespace][field]
+	if !ok {
+		return nil, false
+	}
+	ids, ok := byValue[value]
+	if !ok {
+		return nil, true
+	}
+	result := make([]string, 0, len(ids))
+	for id := range ids {
+		result = append(result, id)
+	}
+	return result, true
+}
+
+// HasUnique reports whether any record other than id currently has value
+// for namespace's field, using the in-memory unique index.
+func (r *Registry) HasUnique(namespace, field, value, id string) bool {
+	ids, ok := r.IndexLookup(namespace, field, value)
+	if !ok {
+		return false
+	}
+	for _, existingID := range ids {
+		if existingID != id {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,341 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// aofMagic identifies a LunarDB append-only-file. aofVersion is bumped
+// whenever the record format changes, so future record types (TTL, hashes,
+// lists) can be added without breaking replay of older logs.
+var aofMagic = [4]byte{'L', 'N', 'D', 'B'}
+
+const aofVersion = 1
+
+// Record opcodes.
+const (
+	opSet byte = iota + 1
+	opDel
+)
+
+// AOFEngine persists every Set/Del as a length-prefixed record in an
+// append-only file, replaying it on startup, and backs reads with an
+// in-memory MemoryEngine for speed.
+type AOFEngine struct {
+	mem *MemoryEngine
+
+	mu           sync.Mutex
+	file         *os.File
+	writer       *bufio.Writer
+	path         string
+	maxBytes     int64
+	fsync        FsyncPolicy
+	bytesWritten int64
+}
+
+// NewAOFEngine opens (creating if necessary) the append-only file at
+// cfg.AOFPath and returns an Engine ready for Replay.
+func NewAOFEngine(cfg Config) (*AOFEngine, error) {
+	if cfg.AOFPath == "" {
+		return nil, fmt.Errorf("storage: aof backend requires AOFPath")
+	}
+	fsync := cfg.Fsync
+	if fsync == "" {
+		fsync = FsyncEverySec
+	}
+
+	file, err := os.OpenFile(cfg.AOFPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("storage: open aof file: %w", err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("storage: stat aof file: %w", err)
+	}
+
+	e := &AOFEngine{
+		mem:          NewMemoryEngine(),
+		file:         file,
+		writer:       bufio.NewWriter(file),
+		path:         cfg.AOFPath,
+		maxBytes:     cfg.AOFMaxBytes,
+		fsync:        fsync,
+		bytesWritten: info.Size(),
+	}
+
+	if fsync == FsyncEverySec {
+		go e.fsyncLoop()
+	}
+
+	return e, nil
+}
+
+func (e *AOFEngine) fsyncLoop() {
+	for {
+		time.Sleep(time.Second)
+		e.mu.Lock()
+		e.writer.Flush()
+		e.file.Sync()
+		e.mu.Unlock()
+	}
+}
+
+func (e *AOFEngine) Get(key string) (string, bool, error) {
+	return e.mem.Get(key)
+}
+
+func (e *AOFEngine) Keys() ([]string, error) {
+	return e.mem.Keys()
+}
+
+func (e *AOFEngine) Set(key, value string) error {
+	return e.appendRecord(opSet, key, value, func() { e.mem.Set(key, value) })
+}
+
+func (e *AOFEngine) Del(key string) (bool, error) {
+	var existed bool
+	err := e.appendRecord(opDel, key, "", func() { existed, _ = e.mem.Del(key) })
+	return existed, err
+}
+
+// appendRecord writes [opcode][keylen][key][vallen][val] to the log, runs
+// apply to update e.mem while still holding e.mu, and triggers background
+// compaction once the log crosses maxBytes.
+func (e *AOFEngine) appendRecord(op byte, key, value string, apply func()) error {
+	e.mu.Lock()
+
+	if err := e.writer.WriteByte(op); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	if err := writeLengthPrefixed(e.writer, key); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+	if err := writeLengthPrefixed(e.writer, value); err != nil {
+		e.mu.Unlock()
+		return err
+	}
+
+	if e.fsync == FsyncAlways {
+		if err := e.writer.Flush(); err != nil {
+			e.mu.Unlock()
+			return err
+		}
+		if err := e.file.Sync(); err != nil {
+			e.mu.Unlock()
+			return err
+		}
+	}
+
+	apply()
+	e.bytesWritten += int64(1 + 4 + len(key) + 4 + len(value))
+	shouldCompact := e.maxBytes > 0 && e.bytesWritten > e.maxBytes
+	e.mu.Unlock()
+
+	if shouldCompact {
+		go e.Compact()
+	}
+	return nil
+}
+
+func writeLengthPrefixed(w io.Writer, s string) error {
+	var lenBuf [4]byte
+	binary.BigEndian.PutUint32(lenBuf[:], uint32(len(s)))
+	if _, err := w.Write(lenBuf[:]); err != nil {
+		return err
+	}
+	_, err := w.Write([]byte(s))
+	return err
+}
+
+// Replay reconstructs the in-memory dataset by replaying every record in
+// the log, in order, from the start of the file.
+func (e *AOFEngine) Replay() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := e.writer.Flush(); err != nil {
+		return err
+	}
+	if _, err := e.file.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	defer e.file.Seek(0, io.SeekEnd)
+
+	reader := bufio.NewReader(e.file)
+
+	var header [4]byte
+	n, err := io.ReadFull(reader, header[:])
+	if err == io.EOF || n == 0 {
+		return e.writeHeaderLocked()
+	}
+	if err != nil {
+		return fmt.Errorf("storage: read aof header: %w", err)
+	}
+	if header != aofMagic {
+		return fmt.Errorf("storage: %q is not a LunarDB aof file", e.path)
+	}
+
+	var version byte
+	if version, err = reader.ReadByte(); err != nil {
+		return fmt.Errorf("storage: read aof version: %w", err)
+	}
+	if version > aofVersion {
+		return fmt.Errorf("storage: aof version %d is newer than this binary understands (%d)", version, aofVersion)
+	}
+
+	for {
+		op, err := reader.ReadByte()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("storage: read aof record: %w", err)
+		}
+
+		key, err := readLengthPrefixed(reader)
+		if err != nil {
+			return fmt.Errorf("storage: read aof key: %w", err)
+		}
+		value, err := readLengthPrefixed(reader)
+		if err != nil {
+			return fmt.Errorf("storage: read aof value: %w", err)
+		}
+
+		switch op {
+		case opSet:
+			e.mem.Set(key, value)
+		case opDel:
+			e.mem.Del(key)
+		default:
+			return fmt.Errorf("storage: unknown aof opcode %d", op)
+		}
+	}
+
+	return nil
+}
+
+func readLengthPrefixed(r io.Reader) (string, error) {
+	var lenBuf [4]byte
+	if _, err := io.ReadFull(r, lenBuf[:]); err != nil {
+		return "", err
+	}
+	length := binary.BigEndian.Uint32(lenBuf[:])
+	buf := make([]byte, length)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func (e *AOFEngine) writeHeaderLocked() error {
+	if _, err := e.file.Write(aofMagic[:]); err != nil {
+		return err
+	}
+	if _, err := e.file.Write([]byte{aofVersion}); err != nil {
+		return err
+	}
+	return e.file.Sync()
+}
+
+// Snapshot rewrites the log from scratch with the current in-memory
+// dataset, discarding the Set/Del history that produced it.
+func (e *AOFEngine) Snapshot() error {
+	return e.Compact()
+}
+
+// Compact rewrites the append-only file to hold only the current key/value
+// pairs, one Set record each, dropping obsolete history (overwritten keys,
+// deletes). Safe to run concurrently with reads.
+//
+// e.mu is held for the entire read-then-rename sequence, not just the
+// close/rename/reopen at the end: appendRecord also takes e.mu, so this is
+// what keeps a concurrent Set/Del from landing after the key snapshot is
+// taken but before the old log (which holds that write) is discarded.
+// Otherwise the write stays visible via e.mem but silently vanishes from
+// durable storage.
+func (e *AOFEngine) Compact() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	keys, err := e.mem.Keys()
+	if err != nil {
+		return err
+	}
+
+	tmpPath := e.path + ".compact"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("storage: create compaction file: %w", err)
+	}
+
+	w := bufio.NewWriter(tmp)
+	if _, err := w.Write(aofMagic[:]); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := w.WriteByte(aofVersion); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	for _, key := range keys {
+		value, exists, err := e.mem.Get(key)
+		if err != nil || !exists {
+			continue
+		}
+		if err := w.WriteByte(opSet); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := writeLengthPrefixed(w, key); err != nil {
+			tmp.Close()
+			return err
+		}
+		if err := writeLengthPrefixed(w, value); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	tmp.Close()
+
+	if err := e.file.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, e.path); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(e.path, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("storage: reopen aof file after compaction: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	e.file = file
+	e.writer = bufio.NewWriter(file)
+	e.bytesWritten = info.Size()
+
+	return nil
+}
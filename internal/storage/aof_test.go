@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestAOFEngineReplayAfterRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.aof")
+	cfg := Config{Backend: "aof", AOFPath: path, Fsync: FsyncAlways}
+
+	first, err := NewAOFEngine(cfg)
+	if err != nil {
+		t.Fatalf("NewAOFEngine: %v", err)
+	}
+	if err := first.Replay(); err != nil {
+		t.Fatalf("initial Replay: %v", err)
+	}
+
+	if err := first.Set("a", "1"); err != nil {
+		t.Fatalf("Set a: %v", err)
+	}
+	if err := first.Set("b", "2"); err != nil {
+		t.Fatalf("Set b: %v", err)
+	}
+	if _, err := first.Del("a"); err != nil {
+		t.Fatalf("Del a: %v", err)
+	}
+
+	// A fresh engine over the same path models a process restart: its
+	// in-memory state starts empty and must come entirely from Replay.
+	restarted, err := NewAOFEngine(cfg)
+	if err != nil {
+		t.Fatalf("NewAOFEngine (restart): %v", err)
+	}
+	if err := restarted.Replay(); err != nil {
+		t.Fatalf("Replay after restart: %v", err)
+	}
+
+	if _, exists, _ := restarted.Get("a"); exists {
+		t.Errorf("Get(a) after restart: got exists=true, want false (deleted before restart)")
+	}
+	if value, exists, _ := restarted.Get("b"); !exists || value != "2" {
+		t.Errorf("Get(b) after restart = %q, %v; want \"2\", true", value, exists)
+	}
+}
+
+func TestAOFEngineCompactDropsHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.aof")
+	cfg := Config{Backend: "aof", AOFPath: path, Fsync: FsyncAlways}
+
+	e, err := NewAOFEngine(cfg)
+	if err != nil {
+		t.Fatalf("NewAOFEngine: %v", err)
+	}
+	if err := e.Replay(); err != nil {
+		t.Fatalf("initial Replay: %v", err)
+	}
+
+	// Overwrite "a" and delete "c" so the log accumulates history Compact
+	// should be able to drop.
+	for _, kv := range [][2]string{{"a", "1"}, {"a", "2"}, {"b", "1"}, {"c", "1"}} {
+		if err := e.Set(kv[0], kv[1]); err != nil {
+			t.Fatalf("Set %v: %v", kv, err)
+		}
+	}
+	if _, err := e.Del("c"); err != nil {
+		t.Fatalf("Del c: %v", err)
+	}
+
+	if err := e.Compact(); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+
+	// Replaying the compacted log from a fresh engine should reproduce
+	// exactly the post-compaction dataset, not the pre-compaction history.
+	reopened, err := NewAOFEngine(cfg)
+	if err != nil {
+		t.Fatalf("NewAOFEngine (reopen): %v", err)
+	}
+	if err := reopened.Replay(); err != nil {
+		t.Fatalf("Replay after compact: %v", err)
+	}
+
+	if value, exists, _ := reopened.Get("a"); !exists || value != "2" {
+		t.Errorf("Get(a) after compact+replay = %q, %v; want \"2\", true", value, exists)
+	}
+	if value, exists, _ := reopened.Get("b"); !exists || value != "1" {
+		t.Errorf("Get(b) after compact+replay = %q, %v; want \"1\", true", value, exists)
+	}
+	if _, exists, _ := reopened.Get("c"); exists {
+		t.Errorf("Get(c) after compact+replay: got exists=true, want false (deleted before compact)")
+	}
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"fmt"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var embeddedBucket = []byte("lunardb")
+
+// EmbeddedEngine persists data in an embedded BoltDB file. Unlike
+// AOFEngine, reads go straight to the on-disk B-tree rather than an
+// in-memory cache, trading raw read throughput for a much smaller memory
+// footprint on large datasets.
+type EmbeddedEngine struct {
+	db *bolt.DB
+}
+
+// NewEmbeddedEngine opens (creating if necessary) the BoltDB file at
+// cfg.EmbeddedPath.
+func NewEmbeddedEngine(cfg Config) (*EmbeddedEngine, error) {
+	if cfg.EmbeddedPath == "" {
+		return nil, fmt.Errorf("storage: embedded backend requires EmbeddedPath")
+	}
+
+	db, err := bolt.Open(cfg.EmbeddedPath, 0o644, nil)
+	if err != nil {
+		return nil, fmt.Errorf("storage: open embedded db: %w", err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(embeddedBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("storage: create bucket: %w", err)
+	}
+
+	return &EmbeddedEngine{db: db}, nil
+}
+
+func (e *EmbeddedEngine) Get(key string) (string, bool, error) {
+	var value string
+	var exists bool
+
+	err := e.db.View(func(tx *bolt.Tx) error {
+		v := tx.Bucket(embeddedBucket).Get([]byte(key))
+		if v != nil {
+			exists = true
+			value = string(v)
+		}
+		return nil
+	})
+	return value, exists, err
+}
+
+func (e *EmbeddedEngine) Set(key, value string) error {
+	return e.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(embeddedBucket).Put([]byte(key), []byte(value))
+	})
+}
+
+func (e *EmbeddedEngine) Del(key string) (bool, error) {
+	existed := false
+	err := e.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(embeddedBucket)
+		existed = bucket.Get([]byte(key)) != nil
+		if existed {
+			return bucket.Delete([]byte(key))
+		}
+		return nil
+	})
+	return existed, err
+}
+
+func (e *EmbeddedEngine) Keys() ([]string, error) {
+	var keys []string
+	err := e.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(embeddedBucket).ForEach(func(k, _ []byte) error {
+			keys = append(keys, string(k))
+			return nil
+		})
+	})
+	return keys, err
+}
+
+// Snapshot is a no-op: BoltDB's B-tree file is always the durable copy.
+func (e *EmbeddedEngine) Snapshot() error { return nil }
+
+// Replay is a no-op: BoltDB opens its own file directly, there is no
+// separate log to reconstruct from.
+func (e *EmbeddedEngine) Replay() error { return nil }
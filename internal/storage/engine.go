@@ -0,0 +1,65 @@
+package storage
+
+import "fmt"
+
+// Engine is a pluggable persistence backend for LunarDB. Implementations
+// range from pure in-memory to durable, disk-backed stores.
+type Engine interface {
+	Get(key string) (string, bool, error)
+	Set(key, value string) error
+	Del(key string) (bool, error)
+	Keys() ([]string, error)
+
+	// Snapshot flushes the current dataset to durable storage in a form
+	// Replay can reconstruct from. For MemoryEngine this is a no-op.
+	Snapshot() error
+
+	// Replay reconstructs the dataset from durable storage, run once on
+	// startup before the engine serves requests.
+	Replay() error
+}
+
+// FsyncPolicy controls how aggressively a durable Engine flushes writes to
+// disk, trading durability for throughput.
+type FsyncPolicy string
+
+const (
+	// FsyncAlways fsyncs after every write. Safest, slowest.
+	FsyncAlways FsyncPolicy = "always"
+	// FsyncEverySec fsyncs on a roughly one-second timer.
+	FsyncEverySec FsyncPolicy = "everysec"
+	// FsyncNever leaves fsync timing to the OS.
+	FsyncNever FsyncPolicy = "no"
+)
+
+// Config selects and configures an Engine.
+type Config struct {
+	// Backend is one of "memory", "aof", or "embedded". Defaults to "memory".
+	Backend string
+
+	// AOFPath is the append-only-file path, used when Backend is "aof".
+	AOFPath string
+	// AOFMaxBytes triggers background compaction into a fresh snapshot once
+	// the log grows past this size. Zero disables automatic compaction.
+	AOFMaxBytes int64
+	// Fsync is the durability/throughput tradeoff for the "aof" backend.
+	// Defaults to FsyncEverySec.
+	Fsync FsyncPolicy
+
+	// EmbeddedPath is the database file path, used when Backend is "embedded".
+	EmbeddedPath string
+}
+
+// New builds the Engine selected by cfg.Backend.
+func New(cfg Config) (Engine, error) {
+	switch cfg.Backend {
+	case "", "memory":
+		return NewMemoryEngine(), nil
+	case "aof":
+		return NewAOFEngine(cfg)
+	case "embedded":
+		return NewEmbeddedEngine(cfg)
+	default:
+		return nil, fmt.Errorf("storage: unknown backend %q", cfg.Backend)
+	}
+}
@@ -0,0 +1,56 @@
+package storage
+
+import "sync"
+
+// MemoryEngine is a pure in-memory Engine: the original LunarDB behavior,
+// kept as the default backend and as the write-through cache other engines
+// build on.
+type MemoryEngine struct {
+	mu   sync.RWMutex
+	data map[string]string
+}
+
+// NewMemoryEngine creates an empty MemoryEngine.
+func NewMemoryEngine() *MemoryEngine {
+	return &MemoryEngine{data: make(map[string]string)}
+}
+
+func (e *MemoryEngine) Get(key string) (string, bool, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	value, exists := e.data[key]
+	return value, exists, nil
+}
+
+func (e *MemoryEngine) Set(key, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.data[key] = value
+	return nil
+}
+
+func (e *MemoryEngine) Del(key string) (bool, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	_, exists := e.data[key]
+	if exists {
+		delete(e.data, key)
+	}
+	return exists, nil
+}
+
+func (e *MemoryEngine) Keys() ([]string, error) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	keys := make([]string, 0, len(e.data))
+	for k := range e.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+// Snapshot is a no-op: there is nothing durable to flush.
+func (e *MemoryEngine) Snapshot() error { return nil }
+
+// Replay is a no-op: there is nothing durable to reconstruct from.
+func (e *MemoryEngine) Replay() error { return nil }